@@ -0,0 +1,127 @@
+package wasmify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// largeDataSectionThreshold is the data section size above which the local
+// analyzer suggests moving data out of the module
+const largeDataSectionThreshold = 1 << 20
+
+// SuggestionKind classifies a size optimization suggestion
+type SuggestionKind string
+
+const (
+	SuggestionUnusedExport     SuggestionKind = "unused-export"
+	SuggestionLargeDataSection SuggestionKind = "large-data-section"
+	SuggestionDebugInfo        SuggestionKind = "debug-info"
+)
+
+// Suggestion is a single way to make a module smaller
+type Suggestion struct {
+	Kind    SuggestionKind `json:"kind"`
+	Message string         `json:"message"`
+	// Target names the export or section the suggestion applies to
+	Target string `json:"target,omitempty"`
+	// EstimatedSavings is the approximate number of bytes the suggestion
+	// would remove
+	EstimatedSavings int64 `json:"estimatedSavings"`
+}
+
+// AnalysisReport describes where a module's size goes and how to reduce it
+type AnalysisReport struct {
+	ModuleID         string       `json:"moduleId,omitempty"`
+	Size             int64        `json:"size"`
+	Suggestions      []Suggestion `json:"suggestions"`
+	EstimatedSavings int64        `json:"estimatedSavings"`
+}
+
+// AnalyzeModule asks the server to analyze an uploaded module for bloat
+func (c *Client) AnalyzeModule(ctx context.Context, moduleID string, opts ...CallOption) (*AnalysisReport, error) {
+	req := &request{
+		op:     "analysis",
+		method: http.MethodGet,
+		path:   "/modules/" + url.PathEscape(moduleID) + "/analysis",
+	}
+
+	var report AnalysisReport
+	if err := c.doJSON(ctx, req, opts, &report); err != nil {
+		return nil, err
+	}
+	if report.ModuleID == "" {
+		report.ModuleID = moduleID
+	}
+
+	return &report, nil
+}
+
+// AnalyzeLocal analyzes a .wasm file without uploading it. It reports debug
+// and name sections that can be stripped, oversized data sections, and
+// linker-generated global exports hosts rarely use. Detecting unused
+// function exports needs call data only the server has, so AnalyzeModule
+// gives more complete results for uploaded modules.
+func AnalyzeLocal(wasmFilePath string) (*AnalysisReport, error) {
+	bin, err := parseWasmFile(wasmFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AnalysisReport{Size: bin.size}
+
+	for _, s := range bin.sections {
+		switch {
+		case s.id == sectionCustom && isDebugSection(s.name):
+			report.add(Suggestion{
+				Kind:             SuggestionDebugInfo,
+				Message:          fmt.Sprintf("strip custom section %q (%d bytes), e.g. with wasm-opt --strip-debug", s.name, s.size),
+				Target:           s.name,
+				EstimatedSavings: s.size,
+			})
+		case s.id == sectionData && s.size > largeDataSectionThreshold:
+			report.add(Suggestion{
+				Kind:    SuggestionLargeDataSection,
+				Message: fmt.Sprintf("data section is %d bytes across %d segments; consider loading large assets at runtime", s.size, len(bin.dataSizes)),
+				Target:  "data",
+				// Compressing or externalizing data rarely removes all of
+				// it, so only claim half.
+				EstimatedSavings: s.size / 2,
+			})
+		}
+	}
+
+	for _, exp := range bin.exports {
+		if exp.kind == externGlobal && strings.HasPrefix(exp.name, "__") {
+			report.add(Suggestion{
+				Kind:             SuggestionUnusedExport,
+				Message:          fmt.Sprintf("global %q is a linker symbol that hosts rarely import; drop it from the export list", exp.name),
+				Target:           exp.name,
+				EstimatedSavings: int64(len(exp.name) + 3),
+			})
+		}
+	}
+
+	sort.SliceStable(report.Suggestions, func(i, j int) bool {
+		return report.Suggestions[i].EstimatedSavings > report.Suggestions[j].EstimatedSavings
+	})
+
+	return report, nil
+}
+
+func (r *AnalysisReport) add(s Suggestion) {
+	r.Suggestions = append(r.Suggestions, s)
+	r.EstimatedSavings += s.EstimatedSavings
+}
+
+// isDebugSection reports whether a custom section only carries debugging
+// information
+func isDebugSection(name string) bool {
+	return name == "name" ||
+		name == "sourceMappingURL" ||
+		name == "external_debug_info" ||
+		strings.HasPrefix(name, ".debug")
+}
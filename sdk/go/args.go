@@ -0,0 +1,163 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// codeInvalidArgs is the error code the server uses for arguments that do
+// not match the function signature
+const codeInvalidArgs = "invalid_args"
+
+// ArgError reports arguments that do not match a function's signature.
+// Expected and Got hold WebAssembly value type names; Index is the first
+// offending argument, or -1 when only the count is wrong.
+type ArgError struct {
+	FunctionName string   `json:"functionName,omitempty"`
+	Expected     []string `json:"expected"`
+	Got          []string `json:"got"`
+	Index        int      `json:"index"`
+}
+
+func (e *ArgError) Error() string {
+	msg := "invalid arguments"
+	if e.FunctionName != "" {
+		msg += " for " + e.FunctionName
+	}
+	msg += fmt.Sprintf(": expected (%s) but got (%s)", strings.Join(e.Expected, ", "), strings.Join(e.Got, ", "))
+	if e.Index >= 0 {
+		msg += fmt.Sprintf(" at arg %d", e.Index)
+	}
+	return msg
+}
+
+// ValidateArgs checks args against the signature of fn, as returned by
+// GetModuleExports, before they are sent. Integers must fit the width of
+// i32 and i64 parameters; any number is accepted for f32 and f64. On a
+// mismatch it returns an *ArgError, the same type the server's own
+// validation failures unwrap to.
+func ValidateArgs(fn ExportedFunction, args []interface{}) error {
+	got := make([]string, len(args))
+	for i, arg := range args {
+		got[i] = argTypeName(arg)
+	}
+
+	index := -1
+	if len(args) == len(fn.Params) {
+		for i, param := range fn.Params {
+			if !argMatches(param, args[i]) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil
+		}
+	}
+
+	return &ArgError{FunctionName: fn.Name, Expected: fn.Params, Got: got, Index: index}
+}
+
+// ValidateCall looks up functionName among funcs and checks args against
+// its signature. A missing export yields an *UnknownFunctionError with a
+// "did you mean" suggestion; mismatched args an *ArgError.
+func ValidateCall(funcs []ExportedFunction, functionName string, args []interface{}) error {
+	fn, err := LookupExport(funcs, functionName)
+	if err != nil {
+		return err
+	}
+	return ValidateArgs(fn, args)
+}
+
+// argMatches reports whether arg can be passed as a parameter of type t
+func argMatches(t string, arg interface{}) bool {
+	f, integer, ok := argNumber(arg)
+	if !ok {
+		return false
+	}
+	switch t {
+	case "i32":
+		return integer && f >= math.MinInt32 && f <= math.MaxUint32
+	case "i64":
+		return integer
+	case "f32", "f64":
+		return true
+	}
+	// reference and vector types are left to the server
+	return true
+}
+
+// argTypeName names the WebAssembly type arg most naturally maps to, or its
+// Go type when it is not a number
+func argTypeName(arg interface{}) string {
+	f, integer, ok := argNumber(arg)
+	switch {
+	case !ok:
+		if arg == nil {
+			return "null"
+		}
+		return reflect.TypeOf(arg).String()
+	case !integer:
+		return "f64"
+	case f >= math.MinInt32 && f <= math.MaxInt32:
+		return "i32"
+	}
+	return "i64"
+}
+
+// argNumber converts numeric args to float64 and reports whether they are
+// integral
+func argNumber(arg interface{}) (f float64, integer bool, ok bool) {
+	switch v := arg.(type) {
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			f, _ = v.Float64()
+			return f, true, true
+		}
+		f, err := v.Float64()
+		return f, false, err == nil
+	}
+
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true, true
+	case reflect.Float32, reflect.Float64:
+		f = rv.Float()
+		return f, f == math.Trunc(f) && !math.IsInf(f, 0), true
+	}
+	return 0, false, false
+}
+
+// argInt returns an integral arg exactly, where argNumber would round
+// integers beyond 2^53. Unsigned values above math.MaxInt64 keep their
+// bits, as a wasm i64 does not distinguish the two.
+func argInt(arg interface{}) (int64, bool) {
+	switch v := arg.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, true
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return int64(u), true
+		}
+	}
+
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), true
+	}
+	if f, integer, ok := argNumber(arg); ok && integer && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f), true
+	}
+	return 0, false
+}
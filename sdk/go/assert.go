@@ -0,0 +1,131 @@
+package wasmify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrOutputMismatch is matched by the error ExecuteAndAssert returns when a
+// result differs from the expected value
+var ErrOutputMismatch = errors.New("output does not match expected value")
+
+// OutputDiff is a single difference between an expected and actual result.
+// Path locates it in JSONPath-like form, e.g. "$.items[2].name"; Expected or
+// Actual is nil when the value is missing on that side.
+type OutputDiff struct {
+	Path     string
+	Expected interface{}
+	Actual   interface{}
+}
+
+// OutputMismatchError describes how a result differs from the expected value
+type OutputMismatchError struct {
+	Expected interface{}
+	Actual   interface{}
+	Diffs    []OutputDiff
+}
+
+func (e *OutputMismatchError) Error() string {
+	parts := make([]string, len(e.Diffs))
+	for i, d := range e.Diffs {
+		parts[i] = fmt.Sprintf("%s: expected %s, got %s", d.Path, formatDiffValue(d.Expected), formatDiffValue(d.Actual))
+	}
+	return fmt.Sprintf("%v: %s", ErrOutputMismatch, strings.Join(parts, "; "))
+}
+
+func (e *OutputMismatchError) Unwrap() error {
+	return ErrOutputMismatch
+}
+
+// ExecuteAndAssert executes a module function and compares its result with
+// expected. Values are compared by their JSON form, so expected may be any
+// value that marshals to the result the module returns. On a mismatch the
+// result is returned together with an *OutputMismatchError.
+func (c *Client) ExecuteAndAssert(ctx context.Context, moduleID, functionName string, args []interface{}, config ExecutionConfig, expected interface{}, opts ...CallOption) (*ExecutionResult, error) {
+	result, err := c.Execute(ctx, moduleID, functionName, args, config, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	want, err := normalizeJSON(expected)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode expected value: %w", err)
+	}
+	got, err := normalizeJSON(result.Result)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	if diffs := diffValues("$", want, got, nil); len(diffs) > 0 {
+		return result, &OutputMismatchError{Expected: want, Actual: got, Diffs: diffs}
+	}
+	return result, nil
+}
+
+// diffValues appends the differences between two normalized JSON values
+func diffValues(path string, want, got interface{}, diffs []OutputDiff) []OutputDiff {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffs = diffValues(path+"."+k, w[k], g[k], diffs)
+		}
+		return diffs
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(w)
+		if len(g) > n {
+			n = len(g)
+		}
+		for i := 0; i < n; i++ {
+			var wi, gi interface{}
+			if i < len(w) {
+				wi = w[i]
+			}
+			if i < len(g) {
+				gi = g[i]
+			}
+			diffs = diffValues(path+"["+strconv.Itoa(i)+"]", wi, gi, diffs)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		diffs = append(diffs, OutputDiff{Path: path, Expected: want, Actual: got})
+	}
+	return diffs
+}
+
+func formatDiffValue(v interface{}) string {
+	if v == nil {
+		return "nothing"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,118 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Execution job states reported before a job has finished; finished jobs
+// report ExecutionSucceeded or ExecutionFailed
+const (
+	ExecutionQueued  = "queued"
+	ExecutionRunning = "running"
+)
+
+// DefaultPollInterval is the WaitForExecution interval used when none is
+// given
+const DefaultPollInterval = time.Second
+
+// ExecuteModuleAsync submits an execution as a background job and returns
+// its ID without waiting for it to run, for functions that run longer
+// than a request can stay open. Follow the job with GetExecutionStatus or
+// WaitForExecution. moduleID, the default function and the config are
+// resolved as by Execute.
+func (c *Client) ExecuteModuleAsync(moduleID, functionName string, args []interface{}, config ExecutionConfig, opts ...CallOption) (string, error) {
+	return c.ExecuteModuleAsyncContext(context.Background(), moduleID, functionName, args, config, opts...)
+}
+
+// ExecuteModuleAsyncContext is like ExecuteModuleAsync but stops when ctx
+// is done
+func (c *Client) ExecuteModuleAsyncContext(ctx context.Context, moduleID, functionName string, args []interface{}, config ExecutionConfig, opts ...CallOption) (string, error) {
+	opts, _ = c.withCorrelationID(opts)
+	moduleID, functionName, config, err := c.prepareExecution(ctx, moduleID, functionName, config, opts)
+	if err != nil {
+		return "", err
+	}
+
+	requestData, err := newExecutionRequestData(functionName, args, config)
+	if err != nil {
+		return "", err
+	}
+	requestData["moduleId"] = moduleID
+
+	req, err := c.newJSONRequest("async execution", http.MethodPost, "/wasm/execute/async", requestData)
+	if err != nil {
+		return "", err
+	}
+	if config.Priority != "" {
+		req.header = http.Header{priorityHeader: {string(config.Priority)}}
+	}
+
+	var data struct {
+		JobID string `json:"jobId"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return "", err
+	}
+	return data.JobID, nil
+}
+
+// GetExecutionStatus fetches the state of an execution job. Until the job
+// has finished, only JobID and Status of the result are set. The result
+// is decoded in the output content type the job was submitted with.
+func (c *Client) GetExecutionStatus(jobID string, opts ...CallOption) (*ExecutionResult, error) {
+	return c.GetExecutionStatusContext(context.Background(), jobID, opts...)
+}
+
+// GetExecutionStatusContext is like GetExecutionStatus but stops when ctx
+// is done
+func (c *Client) GetExecutionStatusContext(ctx context.Context, jobID string, opts ...CallOption) (*ExecutionResult, error) {
+	req := &request{
+		op:     "get execution status",
+		method: http.MethodGet,
+		path:   "/wasm/jobs/" + url.PathEscape(jobID),
+	}
+
+	var data struct {
+		Status            string           `json:"status"`
+		OutputContentType string           `json:"outputContentType"`
+		Result            executionPayload `json:"result"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Status != ExecutionSucceeded && data.Status != ExecutionFailed {
+		return &ExecutionResult{JobID: jobID, Status: data.Status}, nil
+	}
+
+	result, err := data.Result.toResult(ExecutionConfig{OutputContentType: data.OutputContentType})
+	if err != nil {
+		return nil, err
+	}
+	result.Success = data.Status == ExecutionSucceeded
+	result.JobID = jobID
+	result.Status = data.Status
+	return result, data.Result.limitError(result)
+}
+
+// WaitForExecution polls an execution job every pollInterval, or
+// DefaultPollInterval if it is not positive, until the job has finished
+// or ctx is done
+func (c *Client) WaitForExecution(ctx context.Context, jobID string, pollInterval time.Duration, opts ...CallOption) (*ExecutionResult, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	for {
+		result, err := c.GetExecutionStatusContext(ctx, jobID, opts...)
+		if err != nil || result.Status == ExecutionSucceeded || result.Status == ExecutionFailed {
+			return result, err
+		}
+		if err := sleepContext(ctx, pollInterval); err != nil {
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package wasmify
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrAttestationInvalid is matched by the error VerifyAttestation returns
+// when an attestation does not vouch for the module or its builder
+var ErrAttestationInvalid = errors.New("attestation verification failed")
+
+// inTotoStatement is the part of an in-toto statement carrying SLSA
+// provenance that verification looks at. Both the v0.2 and v1 predicate
+// layouts are covered.
+type inTotoStatement struct {
+	Type    string `json:"_type"`
+	Subject []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	PredicateType string `json:"predicateType"`
+	Predicate     struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// dsseEnvelope wraps a signed in-toto statement
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+// AttachAttestation stores a provenance attestation for a module. The
+// attestation must be JSON: an in-toto statement or a DSSE envelope
+// wrapping one.
+func (c *Client) AttachAttestation(ctx context.Context, moduleID string, attestation []byte, opts ...CallOption) error {
+	if !json.Valid(attestation) {
+		return fmt.Errorf("invalid attestation: not JSON")
+	}
+	if err := c.requireFeature(ctx, FeatureAttestations, opts); err != nil {
+		return err
+	}
+
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newJSONRequest("attach attestation", http.MethodPut, "/modules/"+url.PathEscape(moduleID)+"/attestation", map[string]interface{}{
+		"attestation": json.RawMessage(attestation),
+	})
+	if err != nil {
+		return err
+	}
+	return c.doJSON(ctx, req, opts, nil)
+}
+
+// GetAttestation returns the attestation stored for a module, as attached
+func (c *Client) GetAttestation(ctx context.Context, moduleID string, opts ...CallOption) ([]byte, error) {
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &request{
+		op:     "get attestation",
+		method: http.MethodGet,
+		path:   "/modules/" + url.PathEscape(moduleID) + "/attestation",
+	}
+
+	var data struct {
+		Attestation json.RawMessage `json:"attestation"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
+	}
+	return data.Attestation, nil
+}
+
+// VerifyAttestation checks that attestation is SLSA provenance for content
+// with the hex-encoded SHA-256 moduleHash (as in WasmModule.Hash) built by
+// trustedBuilder. Failures match ErrAttestationInvalid. Signatures of a
+// DSSE envelope are not checked here; verify them with the signer's key
+// before trusting the result.
+func VerifyAttestation(attestation []byte, moduleHash, trustedBuilder string) error {
+	stmt, err := parseAttestation(attestation)
+	if err != nil {
+		return err
+	}
+
+	want := normalizeDigest(moduleHash)
+	matched := false
+	for _, s := range stmt.Subject {
+		if want != "" && normalizeDigest(s.Digest["sha256"]) == want {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return fmt.Errorf("%w: no subject has digest sha256:%s", ErrAttestationInvalid, want)
+	}
+
+	builder := stmt.Predicate.RunDetails.Builder.ID
+	if builder == "" {
+		builder = stmt.Predicate.Builder.ID
+	}
+	if builder != trustedBuilder {
+		return fmt.Errorf("%w: built by %q, not the trusted builder %q", ErrAttestationInvalid, builder, trustedBuilder)
+	}
+	return nil
+}
+
+// parseAttestation decodes an in-toto statement, unwrapping a DSSE
+// envelope first if needed
+func parseAttestation(attestation []byte) (*inTotoStatement, error) {
+	var env dsseEnvelope
+	if err := json.Unmarshal(attestation, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAttestationInvalid, err)
+	}
+	if env.PayloadType != "" {
+		payload, err := base64.StdEncoding.DecodeString(env.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid envelope payload: %v", ErrAttestationInvalid, err)
+		}
+		attestation = payload
+	}
+
+	var stmt inTotoStatement
+	if err := json.Unmarshal(attestation, &stmt); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAttestationInvalid, err)
+	}
+	if len(stmt.Subject) == 0 {
+		return nil, fmt.Errorf("%w: not an in-toto statement", ErrAttestationInvalid)
+	}
+	return &stmt, nil
+}
@@ -0,0 +1,166 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultBatchConcurrency bounds the worker pool used when the server has
+// no batch endpoint and the module advertises no concurrency limit
+const defaultBatchConcurrency = 8
+
+// BatchCall is a single function invocation within ExecuteBatch
+type BatchCall struct {
+	FunctionName string
+	Args         []interface{}
+	Config       ExecutionConfig
+}
+
+// ExecuteBatch runs calls against a module and returns their results in
+// order. moduleID may be a pinned or "name@version" reference, resolved
+// once for the whole batch. Calls are sent to the batch endpoint, split so that no request
+// exceeds the module's MaxConcurrency; when the server has no batch
+// endpoint they run as individual executions on a bounded worker pool. If
+// any call fails, the first error is returned together with the results
+// that completed, leaving nil entries for failed calls.
+func (c *Client) ExecuteBatch(moduleID string, calls []BatchCall, opts ...CallOption) ([]*ExecutionResult, error) {
+	return c.ExecuteBatchContext(context.Background(), moduleID, calls, opts...)
+}
+
+// ExecuteBatchContext is like ExecuteBatch but stops when ctx is done
+func (c *Client) ExecuteBatchContext(ctx context.Context, moduleID string, calls []BatchCall, opts ...CallOption) ([]*ExecutionResult, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	opts, _ = c.withCorrelationID(opts)
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The limit is an optimization; without module details the server
+	// still enforces it.
+	limit := 0
+	var defaults ExecutionConfig
+	if module, err := c.GetModuleContext(ctx, moduleID, opts...); err == nil {
+		limit, defaults = module.MaxConcurrency, module.DefaultConfig
+	}
+
+	chunk := len(calls)
+	if limit > 0 && limit < chunk {
+		chunk = limit
+	}
+
+	results := make([]*ExecutionResult, len(calls))
+	for start := 0; start < len(calls); start += chunk {
+		end := start + chunk
+		if end > len(calls) {
+			end = len(calls)
+		}
+
+		part, err := c.executeBatchRequest(ctx, moduleID, calls[start:end], defaults, opts)
+		if start == 0 && isUnsupported(err) {
+			return c.executeConcurrently(ctx, moduleID, calls, limit, opts)
+		}
+		if err != nil {
+			return results, err
+		}
+		copy(results[start:end], part)
+	}
+
+	return results, nil
+}
+
+// executeBatchRequest sends calls to the batch endpoint in one request,
+// filling their configs from the module's defaults and then the client's
+func (c *Client) executeBatchRequest(ctx context.Context, moduleID string, calls []BatchCall, defaults ExecutionConfig, opts []CallOption) ([]*ExecutionResult, error) {
+	wireCalls := make([]map[string]interface{}, len(calls))
+	configs := make([]ExecutionConfig, len(calls))
+	for i, call := range calls {
+		configs[i] = call.Config.withDefaults(defaults).withDefaults(c.config.DefaultExecutionConfig)
+		data, err := newExecutionRequestData(call.FunctionName, call.Args, configs[i])
+		if err != nil {
+			return nil, err
+		}
+		wireCalls[i] = data
+	}
+
+	req, err := c.newJSONRequest("batch execution", http.MethodPost, "/wasm/execute/batch", map[string]interface{}{
+		"moduleId": moduleID,
+		"calls":    wireCalls,
+	})
+	if err != nil {
+		return nil, err
+	}
+	correlationID := c.newCallOptions(opts).correlationID
+	req.onResponse = func(resp *http.Response) {
+		if id := resp.Header.Get(correlationIDHeader); id != "" {
+			correlationID = id
+		}
+	}
+
+	var data struct {
+		Results []executionPayload `json:"results"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Results) != len(calls) {
+		return nil, fmt.Errorf("batch execution returned %d results for %d calls", len(data.Results), len(calls))
+	}
+
+	results := make([]*ExecutionResult, len(calls))
+	for i := range data.Results {
+		if results[i], err = data.Results[i].toResult(configs[i]); err != nil {
+			return nil, err
+		}
+		results[i].CorrelationID = correlationID
+	}
+	return results, nil
+}
+
+// executeConcurrently runs calls as individual executions with at most
+// limit (or defaultBatchConcurrency) in flight
+func (c *Client) executeConcurrently(ctx context.Context, moduleID string, calls []BatchCall, limit int, opts []CallOption) ([]*ExecutionResult, error) {
+	if limit <= 0 || limit > defaultBatchConcurrency {
+		limit = defaultBatchConcurrency
+	}
+
+	results := make([]*ExecutionResult, len(calls))
+	var g errgroup.Group
+	g.SetLimit(limit)
+	for i := range calls {
+		i := i
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result, err := c.Execute(ctx, moduleID, calls[i].FunctionName, calls[i].Args, calls[i].Config, opts...)
+			if err != nil {
+				return fmt.Errorf("call %d: %w", i, err)
+			}
+			results[i] = result
+			return nil
+		})
+	}
+
+	return results, g.Wait()
+}
+
+// isUnsupported reports whether err means the server does not implement the
+// requested endpoint
+func isUnsupported(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestExecuteBatchKeepsResultPositions(t *testing.T) {
+	var batches int32
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/modules/mod-1":
+			writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod", "maxConcurrency": 2})
+		case "/wasm/execute/batch":
+			if atomic.AddInt32(&batches, 1) > 1 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"success":false,"error":"bad call"}`))
+				return
+			}
+			var body struct {
+				Calls []json.RawMessage `json:"calls"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			results := make([]map[string]interface{}, len(body.Calls))
+			for i := range results {
+				results[i] = map[string]interface{}{"result": i}
+			}
+			writeData(t, w, map[string]interface{}{"results": results})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	calls := []BatchCall{{FunctionName: "a"}, {FunctionName: "b"}, {FunctionName: "c"}}
+	results, err := c.ExecuteBatch("mod-1", calls)
+	if err == nil {
+		t.Fatal("got no error from a failed batch")
+	}
+	if len(results) != len(calls) {
+		t.Fatalf("got %d results, want %d", len(results), len(calls))
+	}
+	if results[0] == nil || results[1] == nil || results[2] != nil {
+		t.Errorf("got results %v, want the first two and a nil for the failed call", results)
+	}
+}
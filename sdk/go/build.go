@@ -0,0 +1,159 @@
+package wasmify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Build job states
+const (
+	BuildQueued    = "queued"
+	BuildRunning   = "running"
+	BuildSucceeded = "succeeded"
+	BuildFailed    = "failed"
+)
+
+// BuildOptions describes the module a source build produces
+type BuildOptions struct {
+	Name    string
+	Version string
+	// Args are passed to the language toolchain, e.g. {"target": "wasi"}
+	Args map[string]string
+}
+
+// BuildJob is a server-side compilation of source code to WebAssembly
+type BuildJob struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Language string `json:"language"`
+	// ModuleID is set once the build has succeeded
+	ModuleID string `json:"moduleId,omitempty"`
+	// Logs holds the toolchain output so far
+	Logs       string    `json:"logs,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+}
+
+// Done reports whether the build has finished, successfully or not
+func (j *BuildJob) Done() bool {
+	return j.Status == BuildSucceeded || j.Status == BuildFailed
+}
+
+// BuildModule uploads a source archive, such as a tarball, for the server
+// to compile into a module written in lang. It returns as soon as the
+// build is queued; poll GetBuildJob or follow StreamBuildLogs until it is
+// done. The upload is retried only if the archive can be replayed: when it
+// is seekable, or fits in Config.RetryBufferLimit.
+func (c *Client) BuildModule(ctx context.Context, sourceArchive io.Reader, lang string, options BuildOptions, opts ...CallOption) (*BuildJob, error) {
+	var limit int64
+	if c.newCallOptions(opts).retry.MaxRetries > 0 {
+		limit = c.config.RetryBufferLimit
+	}
+	source, replayable, err := replayableSource(sourceArchive, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := [][2]string{{"language", lang}, {"name", options.Name}, {"version", options.Version}}
+	for k, v := range options.Args {
+		fields = append(fields, [2]string{"arg." + k, v})
+	}
+
+	writer := multipart.NewWriter(io.Discard)
+	req := &request{
+		op:          "build",
+		method:      http.MethodPost,
+		path:        "/builds",
+		stream:      sourceStream(source, writer.Boundary(), fields),
+		contentType: writer.FormDataContentType(),
+		oneShot:     !replayable,
+	}
+
+	var job BuildJob
+	if err := c.doJSON(ctx, req, opts, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// sourceStream returns a request stream that sends the archive from open
+// as the "source" file of a multipart form
+func sourceStream(open func() (io.Reader, error), boundary string, fields [][2]string) func() (io.ReadCloser, int64, error) {
+	// done is closed once the previous attempt stopped reading the
+	// archive, so a rewind cannot race with it
+	var done chan struct{}
+	return func() (io.ReadCloser, int64, error) {
+		if done != nil {
+			<-done
+		}
+		src, err := open()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		pr, pw := io.Pipe()
+		done = make(chan struct{})
+		go func(done chan struct{}) {
+			defer close(done)
+			writer := multipart.NewWriter(pw)
+			err := writer.SetBoundary(boundary)
+			for _, f := range fields {
+				if err == nil {
+					err = writer.WriteField(f[0], f[1])
+				}
+			}
+			if err == nil {
+				var part io.Writer
+				if part, err = writer.CreateFormFile("source", "source.tar.gz"); err == nil {
+					if _, err = io.Copy(part, src); err != nil {
+						err = &bodyError{fmt.Errorf("failed to read source archive: %w", err)}
+					}
+				}
+			}
+			if err == nil {
+				err = writer.Close()
+			}
+			pw.CloseWithError(err)
+		}(done)
+		return pr, -1, nil
+	}
+}
+
+// GetBuildJob fetches the current state of a build
+func (c *Client) GetBuildJob(ctx context.Context, jobID string, opts ...CallOption) (*BuildJob, error) {
+	req := &request{
+		op:     "get build",
+		method: http.MethodGet,
+		path:   "/builds/" + url.PathEscape(jobID),
+	}
+
+	var job BuildJob
+	if err := c.doJSON(ctx, req, opts, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// StreamBuildLogs copies the build's log output to w as the toolchain
+// produces it, returning when the build finishes or ctx is done
+func (c *Client) StreamBuildLogs(ctx context.Context, jobID string, w io.Writer, opts ...CallOption) error {
+	req := &request{
+		op:        "build logs",
+		method:    http.MethodGet,
+		path:      "/builds/" + url.PathEscape(jobID) + "/logs?follow=true",
+		streaming: true,
+	}
+
+	return c.do(ctx, req, opts, func(resp *http.Response) error {
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			return fmt.Errorf("failed to stream build logs: %w", err)
+		}
+		return nil
+	})
+}
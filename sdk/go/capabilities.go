@@ -0,0 +1,113 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Optional server features reported by GetCapabilities
+const (
+	FeatureStreaming      = "streaming"
+	FeatureSnapshots      = "snapshots"
+	FeatureComponentModel = "component-model"
+	FeatureBatchExecution = "batch-execution"
+	FeatureAttestations   = "attestations"
+)
+
+// ErrFeatureUnavailable is matched by the error returned when a method
+// needs a feature the server does not offer
+var ErrFeatureUnavailable = errors.New("feature not available on this server")
+
+// Capabilities describes what the server supports
+type Capabilities struct {
+	// Version is the server version, if reported
+	Version  string   `json:"version,omitempty"`
+	Features []string `json:"features"`
+	// Regions lists the deployment regions the server knows, if reported
+	Regions []string `json:"regions,omitempty"`
+}
+
+// Has reports whether the server offers feature, e.g. FeatureSnapshots
+func (c *Capabilities) Has(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilityCache holds the capabilities fetched by a client
+type capabilityCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+	// err remembers that the server has no capabilities endpoint
+	err error
+
+	// fetch shares one request between concurrent callers, each waiting
+	// only as long as its own context allows
+	fetch flightGroup
+}
+
+func (cc *capabilityCache) get() (*Capabilities, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.caps, cc.err
+}
+
+func (cc *capabilityCache) set(caps *Capabilities, err error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.caps, cc.err = caps, err
+}
+
+// GetCapabilities returns the features the server supports. The answer is
+// fetched once and cached for the lifetime of the client. Transient
+// failures are not cached.
+func (c *Client) GetCapabilities(ctx context.Context, opts ...CallOption) (*Capabilities, error) {
+	if caps, err := c.capabilities.get(); caps != nil || err != nil {
+		return caps, err
+	}
+
+	v, err := c.capabilities.fetch.do(ctx, "", func(ctx context.Context) (interface{}, error) {
+		req := &request{
+			op:     "get capabilities",
+			method: http.MethodGet,
+			path:   "/capabilities",
+		}
+
+		var caps Capabilities
+		if err := c.doJSON(ctx, req, opts, &caps); err != nil {
+			if isUnsupported(err) {
+				c.capabilities.set(nil, err)
+			}
+			return nil, err
+		}
+		c.capabilities.set(&caps, nil)
+		return &caps, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Capabilities), nil
+}
+
+// requireFeature fails with ErrFeatureUnavailable when the server reports
+// that it lacks feature. Servers that predate the capabilities endpoint
+// are given the benefit of the doubt.
+func (c *Client) requireFeature(ctx context.Context, feature string, opts []CallOption) error {
+	caps, err := c.GetCapabilities(ctx, opts...)
+	if isUnsupported(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !caps.Has(feature) {
+		return fmt.Errorf("%w: %s", ErrFeatureUnavailable, feature)
+	}
+	return nil
+}
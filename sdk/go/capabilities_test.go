@@ -0,0 +1,47 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetCapabilitiesWaitIsBoundedByContext(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		writeData(t, w, Capabilities{Features: []string{FeatureStreaming}})
+	})
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.GetCapabilities(context.Background())
+		first <- err
+	}()
+	for atomic.LoadInt32(&requests) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetCapabilities(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v while the fetch was in flight, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("GetCapabilities: %v", err)
+	}
+	caps, err := c.GetCapabilities(context.Background())
+	if err != nil || !caps.Has(FeatureStreaming) {
+		t.Errorf("got %+v, %v from the cache, want streaming", caps, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
@@ -0,0 +1,40 @@
+package wasmify
+
+import (
+	"net/http"
+	"time"
+)
+
+// clockSkewThreshold is the skew beyond which authentication failures are
+// annotated with the measured offset
+const clockSkewThreshold = 30 * time.Second
+
+// ClockSkew returns how far the server's clock is ahead of the local one,
+// as measured from the Date header of the most recent response; negative
+// values mean the local clock is ahead. It is zero until a response with a
+// Date header has been seen. The header has one-second resolution, so
+// small values are noise.
+func (c *Client) ClockSkew() time.Duration {
+	return time.Duration(c.skew.Load())
+}
+
+// recordClockSkew measures the skew against the midpoint of the round
+// trip that produced resp
+func (c *Client) recordClockSkew(resp *http.Response, sent, received time.Time) {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	c.skew.Store(int64(date.Sub(local)))
+}
+
+// significantClockSkew returns the measured skew when it is large enough
+// to explain time-sensitive authentication failures
+func (c *Client) significantClockSkew() time.Duration {
+	skew := c.ClockSkew()
+	if skew < clockSkewThreshold && skew > -clockSkewThreshold {
+		return 0
+	}
+	return skew
+}
@@ -0,0 +1,77 @@
+package wasmify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// readCoalescer collapses identical reads issued at nearly the same time:
+// callers share an in-flight request and, for a short window afterwards,
+// its result. It is not a cache; the window is meant to be well under a
+// second. A shared request runs with the call options of the caller that
+// started it; only a correlation ID keeps a caller's read to itself.
+type readCoalescer struct {
+	window time.Duration
+	group  flightGroup
+
+	mu     sync.Mutex
+	recent map[string]coalescedRead
+}
+
+type coalescedRead struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newReadCoalescer(window time.Duration) *readCoalescer {
+	return &readCoalescer{
+		window: window,
+		recent: make(map[string]coalescedRead),
+	}
+}
+
+// do returns the in-flight or just-completed result for key, calling fn
+// when there is none. Errors are shared with concurrent callers but never
+// reused afterwards. A caller whose ctx ends stops waiting without
+// cancelling the request for the others.
+func (rc *readCoalescer) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if rc.window <= 0 {
+		return fn(ctx)
+	}
+
+	now := time.Now()
+	rc.mu.Lock()
+	if read, ok := rc.recent[key]; ok && now.Before(read.expires) {
+		rc.mu.Unlock()
+		return read.value, nil
+	}
+	rc.mu.Unlock()
+
+	return rc.group.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		v, err := fn(ctx)
+		if err == nil {
+			rc.store(key, v)
+		}
+		return v, err
+	})
+}
+
+func (rc *readCoalescer) store(key string, v interface{}) {
+	now := time.Now()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for k, read := range rc.recent {
+		if !now.Before(read.expires) {
+			delete(rc.recent, k)
+		}
+	}
+	rc.recent[key] = coalescedRead{value: v, expires: now.Add(rc.window)}
+}
+
+// readKey identifies a read for coalescing. Reads tagged with different
+// correlation IDs are kept apart so each is traced as its own request.
+func (c *Client) readKey(r *request, opts []CallOption) string {
+	return r.method + " " + r.path + "\x00" + c.newCallOptions(opts).correlationID
+}
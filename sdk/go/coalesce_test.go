@@ -0,0 +1,88 @@
+package wasmify
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadCoalescingSharesInFlightAndRecentReads(t *testing.T) {
+	const window = 200 * time.Millisecond
+	var hits int32
+	release := make(chan struct{})
+	c := newTestClient(t, Config{ReadCoalesceWindow: window}, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			<-release
+		}
+		writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod"})
+	})
+
+	const callers = 3
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetModule("mod-1"); err != nil {
+				t.Errorf("GetModule: %v", err)
+			}
+		}()
+	}
+	// let every caller join the first request before it completes
+	key := c.readKey(&request{method: http.MethodGet, path: "/modules/mod-1"}, nil)
+	for {
+		c.reads.group.mu.Lock()
+		f := c.reads.group.calls[key]
+		joined := f != nil && f.waiters == callers
+		c.reads.group.mu.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("got %d requests for concurrent reads, want 1", n)
+	}
+
+	if _, err := c.GetModule("mod-1"); err != nil {
+		t.Fatalf("GetModule: %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("got %d requests for a read within the window, want 1", n)
+	}
+
+	time.Sleep(window + 50*time.Millisecond)
+	if _, err := c.GetModule("mod-1"); err != nil {
+		t.Fatalf("GetModule: %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Errorf("got %d requests after the window, want 2", n)
+	}
+}
+
+func TestReadCoalescingKeepsVersionsApart(t *testing.T) {
+	var hits int32
+	c := newTestClient(t, Config{ReadCoalesceWindow: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		writeData(t, w, map[string]interface{}{"id": r.URL.Path, "name": "mod"})
+	})
+
+	v1, err := c.GetModule("mod@1.0.0")
+	if err != nil {
+		t.Fatalf("GetModule: %v", err)
+	}
+	v2, err := c.GetModule("mod@2.0.0")
+	if err != nil {
+		t.Fatalf("GetModule: %v", err)
+	}
+	if v1.ID == v2.ID {
+		t.Errorf("got %q for both versions, want each version's own module", v1.ID)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Errorf("got %d requests, want one per version", n)
+	}
+}
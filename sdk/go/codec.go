@@ -0,0 +1,195 @@
+package wasmify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+
+	"github.com/shamaton/msgpack/v2"
+)
+
+// Content types with built-in codecs
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+// Codec encodes and decodes values in a single wire format
+type Codec interface {
+	// Encode serializes v and returns the bytes with their content type
+	Encode(v interface{}) ([]byte, string, error)
+	// Decode deserializes data into v
+	Decode(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes values as JSON
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(v interface{}) ([]byte, string, error) {
+	data, err := json.Marshal(v)
+	return data, ContentTypeJSON, err
+}
+
+func (JSONCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// isJSONCodec reports whether codec is the built-in JSON codec
+func isJSONCodec(codec Codec) bool {
+	switch codec.(type) {
+	case JSONCodec, *JSONCodec:
+		return true
+	}
+	return false
+}
+
+// normalizeJSON round-trips v through JSON so values of different Go types
+// with the same encoding compare equal
+func normalizeJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MsgpackCodec encodes values as MessagePack
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Encode(v interface{}) ([]byte, string, error) {
+	data, err := msgpack.Marshal(v)
+	return data, ContentTypeMsgpack, err
+}
+
+func (MsgpackCodec) Decode(data []byte, v interface{}) error {
+	if err := msgpack.Unmarshal(data, v); err != nil {
+		return err
+	}
+	if p, ok := v.(*interface{}); ok {
+		*p = normalizeMsgpack(*p)
+	}
+	return nil
+}
+
+// normalizeMsgpack converts the map[interface{}]interface{} values msgpack
+// produces for generic decodes into the map[string]interface{} shape JSON
+// results have
+func normalizeMsgpack(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = normalizeMsgpack(val)
+		}
+		return m
+	case []interface{}:
+		for i := range t {
+			t[i] = normalizeMsgpack(t[i])
+		}
+		return t
+	}
+	return v
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		ContentTypeJSON:         JSONCodec{},
+		ContentTypeMsgpack:      MsgpackCodec{},
+		"application/x-msgpack": MsgpackCodec{},
+	}
+)
+
+// RegisterCodec makes codec available for the given content type in
+// ExecutionConfig.InputContentType and OutputContentType. Registering a
+// content type again replaces its codec.
+func RegisterCodec(contentType string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[normalizeContentType(contentType)] = codec
+}
+
+// lookupCodec returns the codec registered for contentType
+func lookupCodec(contentType string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[normalizeContentType(contentType)]
+	if !ok {
+		return nil, fmt.Errorf("no codec registered for content type %q", contentType)
+	}
+	return codec, nil
+}
+
+// normalizeContentType strips parameters and case from a media type
+func normalizeContentType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// isJSONContentType reports whether values of contentType travel inline in
+// the JSON request envelope; an empty content type means JSON
+func isJSONContentType(contentType string) bool {
+	return contentType == "" || normalizeContentType(contentType) == ContentTypeJSON
+}
+
+// encodeArgs adds args to an execution request in the given content type.
+// JSON args are sent inline; any other format is encoded with its codec and
+// sent base64-encoded in the input field. Nil args are sent as an empty
+// list rather than null, which some servers reject.
+func encodeArgs(requestData map[string]interface{}, args []interface{}, contentType string) error {
+	if args == nil {
+		args = []interface{}{}
+	}
+	if isJSONContentType(contentType) {
+		requestData["args"] = args
+		return nil
+	}
+
+	codec, err := lookupCodec(contentType)
+	if err != nil {
+		return err
+	}
+	data, _, err := codec.Encode(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode args as %s: %w", contentType, err)
+	}
+	requestData["input"] = base64.StdEncoding.EncodeToString(data)
+	requestData["inputContentType"] = normalizeContentType(contentType)
+	return nil
+}
+
+// decodeResult decodes an execution result returned in the given content
+// type. Non-JSON results arrive as base64 strings.
+func decodeResult(result interface{}, contentType string) (interface{}, error) {
+	if isJSONContentType(contentType) || result == nil {
+		return result, nil
+	}
+
+	encoded, ok := result.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected %s result as a base64 string, got %T", contentType, result)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s result: %w", contentType, err)
+	}
+
+	codec, err := lookupCodec(contentType)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := codec.Decode(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode %s result: %w", contentType, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,41 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestExecuteSendsEmptyArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+	}{
+		{"nil", nil},
+		{"empty", []interface{}{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/wasm/execute" {
+					writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod"})
+					return
+				}
+				body, _ = io.ReadAll(r.Body)
+				writeData(t, w, map[string]interface{}{"result": map[string]interface{}{"result": 1}})
+			})
+
+			if _, err := c.ExecuteModule("mod-1", "run", tt.args, nil); err != nil {
+				t.Fatalf("ExecuteModule: %v", err)
+			}
+			var sent map[string]json.RawMessage
+			if err := json.Unmarshal(body, &sent); err != nil {
+				t.Fatalf("failed to decode request body %q: %v", body, err)
+			}
+			if got := string(sent["args"]); got != "[]" {
+				t.Errorf("got args %s, want []", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package wasmify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// configFile is the JSON form of a Config written by Config.Marshal.
+// Fields that hold code or live objects, such as hooks, writers, the HTTP
+// client and the codec, have no JSON form and are left out.
+type configFile struct {
+	APIURL                 string             `json:"apiUrl,omitempty"`
+	APIKey                 string             `json:"apiKey,omitempty"`
+	Timeout                configDuration     `json:"timeout,omitempty"`
+	MaxRetries             int                `json:"maxRetries,omitempty"`
+	RetryBackoff           configDuration     `json:"retryBackoff,omitempty"`
+	RetryBufferLimit       int64              `json:"retryBufferLimit,omitempty"`
+	Debug                  bool               `json:"debug,omitempty"`
+	ReadCoalesceWindow     configDuration     `json:"readCoalesceWindow,omitempty"`
+	Policy                 *policyFile        `json:"policy,omitempty"`
+	MetadataSchema         *MetadataSchema    `json:"metadataSchema,omitempty"`
+	TCPKeepAlive           configDuration     `json:"tcpKeepAlive,omitempty"`
+	KeepAlivePing          bool               `json:"keepAlivePing,omitempty"`
+	KeepAliveInterval      configDuration     `json:"keepAliveInterval,omitempty"`
+	DefaultRegions         []string           `json:"defaultRegions,omitempty"`
+	MaxUploadBytesPerSec   int64              `json:"maxUploadBytesPerSec,omitempty"`
+	MaxDownloadBytesPerSec int64              `json:"maxDownloadBytesPerSec,omitempty"`
+	RequestsPerSecond      float64            `json:"requestsPerSecond,omitempty"`
+	DefaultExecution       *executionDefaults `json:"defaultExecutionConfig,omitempty"`
+}
+
+// policyFile is the JSON form of a Policy
+type policyFile struct {
+	MaxSize          int64    `json:"maxSize,omitempty"`
+	DeniedImports    []string `json:"deniedImports,omitempty"`
+	RequiredMetadata []string `json:"requiredMetadata,omitempty"`
+}
+
+// configDuration is a time.Duration written as a string such as "30s"
+type configDuration time.Duration
+
+func (d configDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *configDuration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s: want a string such as \"30s\"", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = configDuration(v)
+	return nil
+}
+
+// Marshal encodes the settings of c that have a JSON form, for sharing as
+// a template. The API key is replaced by "[REDACTED]"; UnmarshalConfig
+// leaves a redacted key empty so it can be filled in from the environment.
+// Hooks, writers, HTTPClient, Codec, Logger and ContextLabels are not
+// included.
+func (c Config) Marshal() ([]byte, error) {
+	f := configFile{
+		APIURL:                 c.APIURL,
+		Timeout:                configDuration(c.Timeout),
+		MaxRetries:             c.MaxRetries,
+		RetryBackoff:           configDuration(c.RetryBackoff),
+		RetryBufferLimit:       c.RetryBufferLimit,
+		Debug:                  c.Debug,
+		ReadCoalesceWindow:     configDuration(c.ReadCoalesceWindow),
+		TCPKeepAlive:           configDuration(c.TCPKeepAlive),
+		KeepAlivePing:          c.KeepAlivePing,
+		KeepAliveInterval:      configDuration(c.KeepAliveInterval),
+		DefaultRegions:         c.DefaultRegions,
+		MetadataSchema:         c.MetadataSchema,
+		MaxUploadBytesPerSec:   c.MaxUploadBytesPerSec,
+		MaxDownloadBytesPerSec: c.MaxDownloadBytesPerSec,
+		RequestsPerSecond:      c.RequestsPerSecond,
+	}
+	if c.APIKey != "" {
+		f.APIKey = redacted
+	}
+	if p := c.Policy; p != nil {
+		f.Policy = &policyFile{
+			MaxSize:          p.MaxSize,
+			DeniedImports:    p.DeniedImports,
+			RequiredMetadata: p.RequiredMetadata,
+		}
+	}
+	enc, err := encodeExecutionDefaults(c.DefaultExecutionConfig)
+	if err != nil {
+		return nil, err
+	}
+	if enc != "" {
+		f.DefaultExecution = newExecutionDefaults(c.DefaultExecutionConfig)
+	}
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// UnmarshalConfig decodes a config written by Config.Marshal. Unknown
+// fields are rejected so typos in a shared template do not go unnoticed.
+func UnmarshalConfig(data []byte) (Config, error) {
+	var f configFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	c := Config{
+		APIURL:                 f.APIURL,
+		Timeout:                time.Duration(f.Timeout),
+		MaxRetries:             f.MaxRetries,
+		RetryBackoff:           time.Duration(f.RetryBackoff),
+		RetryBufferLimit:       f.RetryBufferLimit,
+		Debug:                  f.Debug,
+		ReadCoalesceWindow:     time.Duration(f.ReadCoalesceWindow),
+		TCPKeepAlive:           time.Duration(f.TCPKeepAlive),
+		KeepAlivePing:          f.KeepAlivePing,
+		KeepAliveInterval:      time.Duration(f.KeepAliveInterval),
+		DefaultRegions:         f.DefaultRegions,
+		MetadataSchema:         f.MetadataSchema,
+		MaxUploadBytesPerSec:   f.MaxUploadBytesPerSec,
+		MaxDownloadBytesPerSec: f.MaxDownloadBytesPerSec,
+		RequestsPerSecond:      f.RequestsPerSecond,
+		DefaultExecutionConfig: f.DefaultExecution.config(),
+	}
+	if s := c.MetadataSchema; s != nil {
+		if _, err := s.patterns(); err != nil {
+			return Config{}, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	if f.APIKey != redacted {
+		c.APIKey = f.APIKey
+	}
+	if p := f.Policy; p != nil {
+		c.Policy = &Policy{
+			MaxSize:          p.MaxSize,
+			DeniedImports:    p.DeniedImports,
+			RequiredMetadata: p.RequiredMetadata,
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,28 @@
+package wasmify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	want := Config{
+		APIURL:           "https://api.example.com",
+		Timeout:          10 * time.Second,
+		MaxRetries:       3,
+		RetryBackoff:     time.Second,
+		RetryBufferLimit: 1 << 20,
+	}
+	data, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got, err := UnmarshalConfig(data)
+	if err != nil {
+		t.Fatalf("UnmarshalConfig: %v", err)
+	}
+	if got.APIURL != want.APIURL || got.Timeout != want.Timeout || got.MaxRetries != want.MaxRetries ||
+		got.RetryBackoff != want.RetryBackoff || got.RetryBufferLimit != want.RetryBufferLimit {
+		t.Errorf("got config %+v from %s, want %+v", got, data, want)
+	}
+}
@@ -0,0 +1,39 @@
+package wasmify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// correlationIDHeader carries the ID that ties related requests together
+// in client and server logs
+const correlationIDHeader = "X-Correlation-ID"
+
+// WithCorrelationID sends id with every request made by the call, so an
+// upload, its deployment and the executions that follow can be joined in
+// the logs by passing them the same ID. Calls without one get a fresh ID,
+// shared by all their retries and sub-requests.
+func WithCorrelationID(id string) CallOption {
+	return func(o *callOptions) {
+		o.correlationID = id
+	}
+}
+
+// withCorrelationID returns opts with a correlation ID set, generating one
+// when the caller did not, and the ID in effect. Calls that fan out into
+// several requests use it so they all share the ID.
+func (c *Client) withCorrelationID(opts []CallOption) ([]CallOption, string) {
+	if id := c.newCallOptions(opts).correlationID; id != "" {
+		return opts, id
+	}
+	id := newCorrelationID()
+	return append(opts[:len(opts):len(opts)], WithCorrelationID(id)), id
+}
+
+func newCorrelationID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
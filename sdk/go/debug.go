@@ -0,0 +1,169 @@
+package wasmify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// debugEnvVar enables the debug transport when set to a true value
+const debugEnvVar = "WASMIFY_DEBUG"
+
+// debugBodyLimit is the number of body bytes a debug dump shows before
+// truncating
+const debugBodyLimit = 4096
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are replaced with a placeholder in debug dumps
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// sensitiveFields matches JSON string fields whose values look like secrets
+var sensitiveFields = regexp.MustCompile(`(?i)("(?:api_?key|token|access_?token|refresh_?token|secret|password)"\s*:\s*)"[^"]*"`)
+
+// debugEnabled reports whether config or the environment turns on the debug
+// transport
+func debugEnabled(config Config) bool {
+	if config.Debug {
+		return true
+	}
+	on, _ := strconv.ParseBool(os.Getenv(debugEnvVar))
+	return on
+}
+
+// debugTransport dumps every request and response it carries with secrets
+// redacted and large bodies truncated
+type debugTransport struct {
+	next   http.RoundTripper
+	labels func(context.Context) map[string]string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newDebugTransport(next http.RoundTripper, w io.Writer, labels func(context.Context) map[string]string) *debugTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if w == nil {
+		w = os.Stderr
+	}
+	return &debugTransport{next: next, w: w, labels: labels}
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.dumpRequest(req)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.write(fmt.Sprintf("<<< %s %s failed: %v\n\n", req.Method, req.URL, err))
+		return nil, err
+	}
+
+	t.dumpResponse(resp)
+	return resp, nil
+}
+
+func (t *debugTransport) dumpRequest(req *http.Request) {
+	clone := req.Clone(req.Context())
+	redactHeaders(clone.Header)
+
+	head, err := httputil.DumpRequestOut(clone, false)
+	if err != nil {
+		t.write(fmt.Sprintf(">>> %s %s (dump failed: %v)\n\n", req.Method, req.URL, err))
+		return
+	}
+
+	var body []byte
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+	case req.GetBody == nil:
+		body = []byte("[streaming body not shown]")
+	default:
+		// GetBody returns a fresh copy, leaving the body being sent intact
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(io.LimitReader(rc, debugBodyLimit+1))
+			rc.Close()
+		}
+	}
+
+	prefix := ">>> "
+	if labels := t.labels(req.Context()); len(labels) > 0 {
+		prefix += "[" + formatLabels(labels) + "] "
+	}
+	t.write(prefix + string(head) + formatDebugBody(body) + "\n")
+}
+
+func (t *debugTransport) dumpResponse(resp *http.Response) {
+	header := resp.Header
+	resp.Header = header.Clone()
+	redactHeaders(resp.Header)
+	head, err := httputil.DumpResponse(resp, false)
+	resp.Header = header
+	if err != nil {
+		t.write(fmt.Sprintf("<<< %s (dump failed: %v)\n\n", resp.Status, err))
+		return
+	}
+
+	if streaming, _ := resp.Request.Context().Value(streamingKey{}).(bool); streaming {
+		t.write("<<< " + string(head) + "[streaming body not shown]\n\n")
+		return
+	}
+
+	// Peek at the start of the body and put it back so callers still read
+	// the full stream
+	prefix, _ := io.ReadAll(io.LimitReader(resp.Body, debugBodyLimit+1))
+	resp.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(prefix), resp.Body),
+		Closer: resp.Body,
+	}
+
+	t.write("<<< " + string(head) + formatDebugBody(prefix) + "\n")
+}
+
+func (t *debugTransport) write(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = io.WriteString(t.w, s)
+}
+
+// formatDebugBody redacts secrets in body and truncates it to the debug
+// limit
+func formatDebugBody(body []byte) string {
+	truncated := len(body) > debugBodyLimit
+	if truncated {
+		body = body[:debugBodyLimit]
+	}
+	out := sensitiveFields.ReplaceAllString(string(body), `$1"`+redacted+`"`)
+	if truncated {
+		out += "... [truncated]"
+	}
+	return out
+}
+
+func redactHeaders(h http.Header) {
+	for _, name := range sensitiveHeaders {
+		if h.Get(name) != "" {
+			h.Set(name, redacted)
+		}
+	}
+}
+
+// readCloser pairs a reader with the closer of the stream it wraps
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,135 @@
+package wasmify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// executionDefaults is the wire form of a module's default ExecutionConfig,
+// stored with the module at upload time. Only fields that are set are sent.
+type executionDefaults struct {
+	MemoryMin            int                    `json:"memoryMin,omitempty"`
+	MemoryMax            int                    `json:"memoryMax,omitempty"`
+	MemoryLimitBytes     int64                  `json:"memoryLimitBytes,omitempty"`
+	MemoryGrowLimit      int                    `json:"memoryGrowLimit,omitempty"`
+	MaxExecutionTime     int64                  `json:"maxExecutionTime,omitempty"`
+	EnableWasi           *bool                  `json:"enableWasi,omitempty"`
+	RandomSeed           string                 `json:"randomSeed,omitempty"`
+	InputContentType     string                 `json:"inputContentType,omitempty"`
+	OutputContentType    string                 `json:"outputContentType,omitempty"`
+	Priority             Priority               `json:"priority,omitempty"`
+	SnapshotID           string                 `json:"snapshotId,omitempty"`
+	RetryOnErrorPatterns []string               `json:"retryOnErrorPatterns,omitempty"`
+	MaxErrorRetries      int                    `json:"maxErrorRetries,omitempty"`
+	Extra                map[string]interface{} `json:"extra,omitempty"`
+}
+
+func newExecutionDefaults(c ExecutionConfig) *executionDefaults {
+	d := &executionDefaults{
+		MemoryMin:            c.MemoryMin,
+		MemoryMax:            c.MemoryMax,
+		MemoryLimitBytes:     c.MemoryLimitBytes,
+		MemoryGrowLimit:      c.MemoryGrowLimit,
+		MaxExecutionTime:     c.MaxExecutionTime.Milliseconds(),
+		EnableWasi:           c.EnableWasi,
+		InputContentType:     c.InputContentType,
+		OutputContentType:    c.OutputContentType,
+		Priority:             c.Priority,
+		SnapshotID:           c.SnapshotID,
+		RetryOnErrorPatterns: c.RetryOnErrorPatterns,
+		MaxErrorRetries:      c.MaxErrorRetries,
+		Extra:                c.Extra,
+	}
+	if c.RandomSeed != nil {
+		// A string, like the execution config, to keep all 64 bits
+		d.RandomSeed = strconv.FormatUint(*c.RandomSeed, 10)
+	}
+	return d
+}
+
+func (d *executionDefaults) config() ExecutionConfig {
+	if d == nil {
+		return ExecutionConfig{}
+	}
+	c := ExecutionConfig{
+		MemoryMin:            d.MemoryMin,
+		MemoryMax:            d.MemoryMax,
+		MemoryLimitBytes:     d.MemoryLimitBytes,
+		MemoryGrowLimit:      d.MemoryGrowLimit,
+		MaxExecutionTime:     time.Duration(d.MaxExecutionTime) * time.Millisecond,
+		EnableWasi:           d.EnableWasi,
+		InputContentType:     d.InputContentType,
+		OutputContentType:    d.OutputContentType,
+		Priority:             d.Priority,
+		SnapshotID:           d.SnapshotID,
+		RetryOnErrorPatterns: d.RetryOnErrorPatterns,
+		MaxErrorRetries:      d.MaxErrorRetries,
+		Extra:                d.Extra,
+	}
+	if seed, err := strconv.ParseUint(d.RandomSeed, 10, 64); err == nil {
+		c.RandomSeed = &seed
+	}
+	return c
+}
+
+// encodeExecutionDefaults returns the JSON stored for c, or "" when c sets
+// nothing
+func encodeExecutionDefaults(c ExecutionConfig) (string, error) {
+	data, err := json.Marshal(newExecutionDefaults(c))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode default config: %w", err)
+	}
+	if string(data) == "{}" {
+		return "", nil
+	}
+	return string(data), nil
+}
+
+// Run calls the module's default function, declared with
+// UploadOptions.DefaultFunction, under its default config
+func (c *Client) Run(ctx context.Context, moduleID string, args []interface{}, opts ...CallOption) (*ExecutionResult, error) {
+	return c.Execute(ctx, moduleID, "", args, ExecutionConfig{}, opts...)
+}
+
+// storedDefaults are the defaults a module was uploaded with
+type storedDefaults struct {
+	function string
+	config   ExecutionConfig
+}
+
+// moduleDefaults fills in the default function of a module for a call
+// that names no function, and the module's default config for any call.
+// Fields of config that are set win over the module's defaults. A call
+// that names its function does not depend on the lookup: if the module
+// cannot be fetched it simply runs without module defaults.
+func (c *Client) moduleDefaults(ctx context.Context, moduleID, functionName string, config ExecutionConfig, opts []CallOption) (string, ExecutionConfig, error) {
+	d, err := c.moduleDefaultsOf(ctx, moduleID, opts)
+	if functionName != "" {
+		return functionName, config.withDefaults(d.config), nil
+	}
+	if err != nil {
+		return "", config, err
+	}
+	if d.function == "" {
+		return "", config, fmt.Errorf("no function given and module %s has no default function", moduleID)
+	}
+	return d.function, config.withDefaults(d.config), nil
+}
+
+// moduleDefaultsOf fetches the defaults of a module. They are fixed at
+// upload, so each module is looked up once per client.
+func (c *Client) moduleDefaultsOf(ctx context.Context, moduleID string, opts []CallOption) (storedDefaults, error) {
+	if v, ok := c.defaults.Load(moduleID); ok {
+		return v.(storedDefaults), nil
+	}
+	module, err := c.GetModuleContext(ctx, moduleID, opts...)
+	if err != nil {
+		return storedDefaults{}, err
+	}
+	d := storedDefaults{function: module.DefaultFunction, config: module.DefaultConfig}
+	c.defaults.Store(moduleID, d)
+	return d, nil
+}
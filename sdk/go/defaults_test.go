@@ -0,0 +1,80 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestExecuteAppliesModuleDefaultsToNamedFunction(t *testing.T) {
+	var sent struct {
+		FunctionName string   `json:"functionName"`
+		Priority     Priority `json:"priority"`
+	}
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wasm/execute" {
+			writeData(t, w, map[string]interface{}{
+				"id":              "mod-1",
+				"name":            "mod",
+				"defaultFunction": "main",
+				"defaultConfig":   map[string]interface{}{"priority": PriorityHigh},
+			})
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		writeData(t, w, map[string]interface{}{"result": map[string]interface{}{"result": 1}})
+	})
+
+	if _, err := c.ExecuteModule("mod-1", "other", nil, nil); err != nil {
+		t.Fatalf("ExecuteModule: %v", err)
+	}
+	if sent.FunctionName != "other" {
+		t.Errorf("got function %q, want other", sent.FunctionName)
+	}
+	if sent.Priority != PriorityHigh {
+		t.Errorf("got priority %q, want the module default %q", sent.Priority, PriorityHigh)
+	}
+}
+
+func TestExecuteNamedFunctionSurvivesFailedLookup(t *testing.T) {
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wasm/execute" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"success":false,"error":"forbidden"}`))
+			return
+		}
+		writeData(t, w, map[string]interface{}{"result": map[string]interface{}{"result": 1}})
+	})
+
+	if _, err := c.ExecuteModule("mod-1", "run", nil, nil); err != nil {
+		t.Fatalf("ExecuteModule: %v", err)
+	}
+	if _, err := c.ExecuteModule("mod-1", "", nil, nil); err == nil {
+		t.Error("got no error for a default function that could not be looked up")
+	}
+}
+
+func TestModuleDefaultsAreFetchedOnce(t *testing.T) {
+	var lookups int32
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wasm/execute" {
+			atomic.AddInt32(&lookups, 1)
+			writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod", "defaultFunction": "main"})
+			return
+		}
+		writeData(t, w, map[string]interface{}{"result": map[string]interface{}{"result": 1}})
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.ExecuteModule("mod-1", "", nil, nil); err != nil {
+			t.Fatalf("ExecuteModule: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&lookups); n != 1 {
+		t.Errorf("got %d module lookups, want 1", n)
+	}
+}
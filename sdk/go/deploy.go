@@ -0,0 +1,461 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Region and deployment states reported by the server
+const (
+	DeploymentPending   = "pending"
+	DeploymentDeploying = "deploying"
+	DeploymentActive    = "active"
+	DeploymentFailed    = "failed"
+	// DeploymentPaused keeps the deployment's configuration and instances
+	// but stops it serving traffic; see PauseDeployment
+	DeploymentPaused = "paused"
+)
+
+// codeInvalidDeploymentState is the error code the server uses when an
+// action does not apply to a deployment in its current state
+const codeInvalidDeploymentState = "invalid_deployment_state"
+
+// DeployStrategy controls how a deployment replaces the version already
+// serving
+type DeployStrategy string
+
+const (
+	// StrategyRecreate stops the old version before starting the new one
+	StrategyRecreate DeployStrategy = "recreate"
+	// StrategyRolling replaces instances of the old version gradually
+	StrategyRolling DeployStrategy = "rolling"
+	// StrategyBlueGreen keeps the old version serving until the new one
+	// passes its health checks, then switches all traffic at once
+	StrategyBlueGreen DeployStrategy = "blue-green"
+)
+
+// Rollout phases reported in Deployment.Phase
+const (
+	PhaseProvisioning = "provisioning"
+	PhaseHealthCheck  = "health-check"
+	PhaseCutover      = "cutover"
+	PhaseComplete     = "complete"
+)
+
+// RegionState is the rollout state of a deployment in one region
+type RegionState struct {
+	Region string `json:"region"`
+	Status string `json:"status"`
+	// Error explains why the region failed
+	Error string `json:"error,omitempty"`
+	// Replicas is the number of instances wanted in the region and
+	// ReadyReplicas how many are serving
+	Replicas      int `json:"replicas,omitempty"`
+	ReadyReplicas int `json:"readyReplicas,omitempty"`
+	// Weight is the percentage of traffic the region receives
+	Weight int `json:"weight,omitempty"`
+}
+
+// Deployment describes a module deployment and its rollout across regions
+type Deployment struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	ModuleID    string `json:"moduleId"`
+	Environment string `json:"environment,omitempty"`
+	Status      string `json:"status"`
+	// Strategy is the cutover strategy in use and Phase how far the
+	// rollout has progressed through it
+	Strategy DeployStrategy `json:"strategy,omitempty"`
+	Phase    string         `json:"phase,omitempty"`
+	Regions  []RegionState  `json:"regions,omitempty"`
+	// Replicas and ReadyReplicas total the instances across regions
+	Replicas      int `json:"replicas,omitempty"`
+	ReadyReplicas int `json:"readyReplicas,omitempty"`
+	// Weight is the percentage of traffic routed to this deployment
+	// during a rollout, and Restarts counts instance restarts
+	Weight    int       `json:"weight,omitempty"`
+	Restarts  int       `json:"restarts,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// FailedRegions returns the regions whose rollout failed
+func (d *Deployment) FailedRegions() []RegionState {
+	var failed []RegionState
+	for _, r := range d.Regions {
+		if r.Status == DeploymentFailed {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// RegionDeployError reports the regions a deployment failed in. The regions
+// that succeeded keep serving; RetryFailedRegions re-attempts only the
+// failed ones.
+type RegionDeployError struct {
+	DeploymentID string
+	Failed       []RegionState
+}
+
+func (e *RegionDeployError) Error() string {
+	parts := make([]string, len(e.Failed))
+	for i, r := range e.Failed {
+		parts[i] = r.Region
+		if r.Error != "" {
+			parts[i] += ": " + r.Error
+		}
+	}
+	return fmt.Sprintf("deployment %s failed in %d region(s): %s", e.DeploymentID, len(e.Failed), strings.Join(parts, "; "))
+}
+
+// Deployment defaults used for DeployConfig fields left zero
+const (
+	defaultDeployMemory      = "128MB"
+	defaultDeployCPU         = "100m"
+	defaultDeployReplicas    = 3
+	defaultDeployEnvironment = "production"
+)
+
+// DeployConfig sizes the instances of a deployment. Zero fields keep the
+// defaults of 128MB memory, 100m CPU and 3 replicas in the production
+// environment at the edge.
+type DeployConfig struct {
+	// Memory and CPU are resource quantities such as "256MB" and "500m"
+	Memory string
+	CPU    string
+	// Replicas is the number of instances per region
+	Replicas    int
+	Environment string
+	// Edge set to false turns off edge placement; nil keeps it on
+	Edge *bool
+}
+
+// DeploySpec describes where and how to deploy a module
+type DeploySpec struct {
+	// Regions lists the edge regions to deploy to. Empty uses
+	// Config.DefaultRegions, and deploys globally if that is empty too.
+	Regions []string
+	// Strategy controls cutover from the version currently serving; empty
+	// leaves the server default
+	Strategy DeployStrategy
+	// Config sizes the deployment's instances
+	Config DeployConfig
+}
+
+// Deploy deploys a module to every region in spec and returns the
+// deployment with per-region state. If some regions failed, the deployment
+// is returned together with a *RegionDeployError. moduleID may be a pinned
+// "sha256:<hex>" reference.
+func (c *Client) Deploy(ctx context.Context, moduleID string, spec DeploySpec, opts ...CallOption) (*Deployment, error) {
+	if spec.Config.Replicas < 0 {
+		return nil, fmt.Errorf("invalid replicas %d: must not be negative", spec.Config.Replicas)
+	}
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Regions, err = c.resolveRegions(ctx, spec.Regions, opts); err != nil {
+		return nil, err
+	}
+
+	req, err := c.newJSONRequest("deployment", http.MethodPost, "/deployments", deployRequestData(moduleID, spec))
+	if err != nil {
+		return nil, err
+	}
+
+	var deployment Deployment
+	if err := c.doJSON(ctx, req, opts, &deployment); err != nil {
+		return nil, err
+	}
+
+	return &deployment, deployment.regionError()
+}
+
+// resolveRegions falls back to the client default regions when none are
+// given and checks the result against the regions the server reports.
+// Servers that report none are not checked, and neither is anything when
+// the capabilities cannot be fetched: the check only gives a clearer
+// error than the server would, so it never blocks a deployment.
+func (c *Client) resolveRegions(ctx context.Context, regions []string, opts []CallOption) ([]string, error) {
+	if len(regions) == 0 {
+		regions = c.config.DefaultRegions
+	}
+	if len(regions) == 0 {
+		return nil, nil
+	}
+
+	caps, err := c.GetCapabilities(ctx, opts...)
+	if err != nil || len(caps.Regions) == 0 {
+		return regions, nil
+	}
+
+	known := make(map[string]bool, len(caps.Regions))
+	for _, r := range caps.Regions {
+		known[r] = true
+	}
+	for _, r := range regions {
+		if !known[r] {
+			return nil, fmt.Errorf("unknown region %q; the server offers %s", r, strings.Join(caps.Regions, ", "))
+		}
+	}
+	return regions, nil
+}
+
+// GetDeployment fetches a deployment by ID
+func (c *Client) GetDeployment(deploymentID string, opts ...CallOption) (*Deployment, error) {
+	return c.GetDeploymentContext(context.Background(), deploymentID, opts...)
+}
+
+// GetDeploymentContext is like GetDeployment but stops when ctx is done
+func (c *Client) GetDeploymentContext(ctx context.Context, deploymentID string, opts ...CallOption) (*Deployment, error) {
+	req := &request{
+		op:     "get deployment",
+		method: http.MethodGet,
+		path:   "/deployments/" + url.PathEscape(deploymentID),
+	}
+
+	var deployment Deployment
+	if err := c.doJSON(ctx, req, opts, &deployment); err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// ListDeployments returns the deployments of a module, or of every module
+// when moduleID is empty
+func (c *Client) ListDeployments(moduleID string, opts ...CallOption) ([]*Deployment, error) {
+	return c.ListDeploymentsContext(context.Background(), moduleID, opts...)
+}
+
+// ListDeploymentsContext is like ListDeployments but stops when ctx is done
+func (c *Client) ListDeploymentsContext(ctx context.Context, moduleID string, opts ...CallOption) ([]*Deployment, error) {
+	path := "/deployments"
+	if moduleID != "" {
+		path += "?" + url.Values{"moduleId": {moduleID}}.Encode()
+	}
+	req := &request{
+		op:     "list deployments",
+		method: http.MethodGet,
+		path:   path,
+	}
+
+	var deployments []*Deployment
+	err := c.doJSONList(ctx, req, opts, func(decode func(interface{}) error) error {
+		var d Deployment
+		if err := decode(&d); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		deployments = append(deployments, &d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deployments, nil
+}
+
+// WaitForDeployment polls a deployment every pollInterval, or
+// DefaultPollInterval if it is not positive, until it has left the
+// pending and deploying states or ctx is done. A deployment that ends
+// failed in some regions is returned with a *RegionDeployError.
+func (c *Client) WaitForDeployment(ctx context.Context, deploymentID string, pollInterval time.Duration, opts ...CallOption) (*Deployment, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	for {
+		deployment, err := c.GetDeploymentContext(ctx, deploymentID, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if deployment.Status != DeploymentPending && deployment.Status != DeploymentDeploying {
+			return deployment, deployment.regionError()
+		}
+		if err := sleepContext(ctx, pollInterval); err != nil {
+			return deployment, err
+		}
+	}
+}
+
+// RetryFailedRegions re-attempts the rollout in the regions where a
+// deployment failed, leaving the regions that succeeded untouched. Regions
+// that fail again are reported in a *RegionDeployError alongside the
+// updated deployment.
+func (c *Client) RetryFailedRegions(ctx context.Context, deploymentID string, opts ...CallOption) (*Deployment, error) {
+	deployment, err := c.GetDeploymentContext(ctx, deploymentID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := deployment.FailedRegions()
+	if len(failed) == 0 {
+		return deployment, nil
+	}
+
+	regions := make([]string, len(failed))
+	for i, r := range failed {
+		regions[i] = r.Region
+	}
+
+	req, err := c.newJSONRequest("region retry", http.MethodPost, "/deployments/"+url.PathEscape(deploymentID)+"/regions/retry", map[string]interface{}{
+		"regions": regions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var updated Deployment
+	if err := c.doJSON(ctx, req, opts, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, updated.regionError()
+}
+
+// DeploymentStateError is returned when a deployment cannot be paused,
+// resumed or rolled back from the state it is in, e.g. resuming one that
+// is not paused.
+type DeploymentStateError struct {
+	DeploymentID string
+	Action       string
+	// Status is the deployment's state when known
+	Status string
+	Err    error
+}
+
+func (e *DeploymentStateError) Error() string {
+	msg := fmt.Sprintf("cannot %s deployment %s", e.Action, e.DeploymentID)
+	if e.Status != "" {
+		msg += " in state " + e.Status
+	}
+	return msg
+}
+
+func (e *DeploymentStateError) Unwrap() error {
+	return e.Err
+}
+
+// PauseDeployment stops a deployment serving traffic while keeping its
+// configuration, so ResumeDeployment can bring it back without a redeploy.
+// A deployment that cannot be paused yields a *DeploymentStateError.
+func (c *Client) PauseDeployment(ctx context.Context, deploymentID string, opts ...CallOption) (*Deployment, error) {
+	return c.deploymentAction(ctx, deploymentID, "pause", opts)
+}
+
+// ResumeDeployment makes a paused deployment serve traffic again. A
+// deployment that is not paused yields a *DeploymentStateError.
+func (c *Client) ResumeDeployment(ctx context.Context, deploymentID string, opts ...CallOption) (*Deployment, error) {
+	return c.deploymentAction(ctx, deploymentID, "resume", opts)
+}
+
+// RollbackDeployment reverts a deployment to the last version that served
+// before it and returns the resulting active deployment. A deployment with
+// nothing to roll back to yields a *DeploymentStateError.
+func (c *Client) RollbackDeployment(deploymentID string, opts ...CallOption) (*Deployment, error) {
+	return c.RollbackDeploymentContext(context.Background(), deploymentID, opts...)
+}
+
+// RollbackDeploymentContext is like RollbackDeployment but stops when ctx
+// is done
+func (c *Client) RollbackDeploymentContext(ctx context.Context, deploymentID string, opts ...CallOption) (*Deployment, error) {
+	return c.deploymentAction(ctx, deploymentID, "rollback", opts)
+}
+
+// DeleteDeployment tears down a deployment and stops it serving traffic
+func (c *Client) DeleteDeployment(deploymentID string, opts ...CallOption) error {
+	return c.DeleteDeploymentContext(context.Background(), deploymentID, opts...)
+}
+
+// DeleteDeploymentContext is like DeleteDeployment but stops when ctx is
+// done
+func (c *Client) DeleteDeploymentContext(ctx context.Context, deploymentID string, opts ...CallOption) error {
+	req := &request{
+		op:     "delete deployment",
+		method: http.MethodDelete,
+		path:   "/deployments/" + url.PathEscape(deploymentID),
+	}
+	return c.doJSON(ctx, req, opts, nil)
+}
+
+// deploymentAction posts action to a deployment, reporting a
+// *DeploymentStateError when its state does not allow it
+func (c *Client) deploymentAction(ctx context.Context, deploymentID, action string, opts []CallOption) (*Deployment, error) {
+	req := &request{
+		op:     action + " deployment",
+		method: http.MethodPost,
+		path:   "/deployments/" + url.PathEscape(deploymentID) + "/" + action,
+	}
+
+	var deployment Deployment
+	err := c.doJSON(ctx, req, opts, &deployment)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.Code == codeInvalidDeploymentState || apiErr.StatusCode == http.StatusConflict) {
+		stateErr := &DeploymentStateError{DeploymentID: deploymentID, Action: action, Err: err}
+		if current, gerr := c.GetDeploymentContext(ctx, deploymentID, opts...); gerr == nil {
+			stateErr.Status = current.Status
+		}
+		return nil, stateErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// regionError returns a *RegionDeployError when any region failed
+func (d *Deployment) regionError() error {
+	failed := d.FailedRegions()
+	if len(failed) == 0 {
+		return nil
+	}
+	return &RegionDeployError{DeploymentID: d.ID, Failed: failed}
+}
+
+// deployRequestData builds the body of a deployment request
+func deployRequestData(moduleID string, spec DeploySpec) map[string]interface{} {
+	cfg := spec.Config
+	if cfg.Memory == "" {
+		cfg.Memory = defaultDeployMemory
+	}
+	if cfg.CPU == "" {
+		cfg.CPU = defaultDeployCPU
+	}
+	if cfg.Replicas == 0 {
+		cfg.Replicas = defaultDeployReplicas
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = defaultDeployEnvironment
+	}
+	edge := cfg.Edge == nil || *cfg.Edge
+
+	requestData := map[string]interface{}{
+		"moduleId":    moduleID,
+		"environment": cfg.Environment,
+		"region":      "global",
+		"config": map[string]interface{}{
+			"memory":   cfg.Memory,
+			"cpu":      cfg.CPU,
+			"replicas": cfg.Replicas,
+			"edge":     edge,
+		},
+	}
+
+	if len(spec.Regions) > 0 {
+		// region stays the primary region for servers that only track one
+		requestData["region"] = spec.Regions[0]
+		requestData["regions"] = spec.Regions
+	}
+	if spec.Strategy != "" {
+		requestData["strategy"] = spec.Strategy
+	}
+
+	return requestData
+}
@@ -0,0 +1,31 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestDeployWithoutCapabilities(t *testing.T) {
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/capabilities":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"success":false,"error":"capabilities unavailable"}`))
+		case "/deployments":
+			writeData(t, w, map[string]interface{}{"id": "dep-1", "moduleId": "mod-1", "status": "pending"})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	deployment, err := c.Deploy(context.Background(), "mod-1", DeploySpec{Regions: []string{"us-east-1"}})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+	if deployment.ID != "dep-1" {
+		t.Errorf("got deployment %q, want dep-1", deployment.ID)
+	}
+}
@@ -0,0 +1,266 @@
+package wasmify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DownloadModule streams the .wasm binary of a module into w and returns the
+// number of bytes written. For a pinned "sha256:<hex>" reference the stream
+// is hashed as it is written and ErrDigestMismatch is returned if it does not
+// match the pin; w will already have received the content by then.
+func (c *Client) DownloadModule(moduleID string, w io.Writer, opts ...CallOption) (int64, error) {
+	return c.DownloadModuleContext(context.Background(), moduleID, w, opts...)
+}
+
+// DownloadModuleContext is like DownloadModule but stops when ctx is done
+func (c *Client) DownloadModuleContext(ctx context.Context, moduleID string, w io.Writer, opts ...CallOption) (int64, error) {
+	moduleID, err := c.resolveVersionRef(ctx, moduleID, opts)
+	if err != nil {
+		return 0, err
+	}
+	digest, pinned, err := parsePin(moduleID)
+	if err != nil {
+		return 0, err
+	}
+
+	req := &request{
+		op:       "download",
+		method:   http.MethodGet,
+		path:     "/modules/" + url.PathEscape(moduleID) + "/download",
+		download: true,
+	}
+
+	var written int64
+	err = c.do(ctx, req, opts, func(resp *http.Response) error {
+		dst := w
+		h := sha256.New()
+		if pinned {
+			dst = io.MultiWriter(w, h)
+		}
+
+		n, err := io.Copy(dst, throttle(ctx, resp.Body, c.downloadLimit))
+		written = n
+		if err != nil {
+			return fmt.Errorf("failed to download module: %w", err)
+		}
+
+		if pinned {
+			if got := hex.EncodeToString(h.Sum(nil)); got != digest {
+				return fmt.Errorf("%w: downloaded %s, got sha256:%s", ErrDigestMismatch, moduleID, got)
+			}
+		}
+		return nil
+	})
+
+	return written, err
+}
+
+// DownloadModuleBuffered downloads a module when the caller has no writer
+// to stream into. Binaries up to threshold bytes are held in memory and
+// larger ones spill to a temporary file; zero uses DefaultSpillThreshold.
+// The returned buffer is positioned at the start and must be closed.
+func (c *Client) DownloadModuleBuffered(ctx context.Context, moduleID string, threshold int64, opts ...CallOption) (*SpillBuffer, error) {
+	buf := NewSpillBuffer(threshold)
+	if _, err := c.DownloadModuleContext(ctx, moduleID, buf, opts...); err != nil {
+		buf.Close()
+		return nil, err
+	}
+	return buf, nil
+}
+
+// DefaultDownloadChunkSize is the range size used by parallel downloads
+const DefaultDownloadChunkSize = 4 << 20
+
+// DownloadOptions configures DownloadModuleWithOptions
+type DownloadOptions struct {
+	// Parallelism is the number of concurrent range requests; one or less
+	// downloads in a single stream
+	Parallelism int
+	// ChunkSize is the size of each range, DefaultDownloadChunkSize if
+	// zero
+	ChunkSize int64
+}
+
+// DownloadModuleWithOptions is like DownloadModule but can fetch large
+// binaries as concurrent byte ranges when the server supports them. Ranges
+// are written to w in order as they complete, so w needs no random access;
+// at most twice Parallelism chunks are held in memory. The assembled
+// content is checked against the pinned digest or, for ordinary IDs, the
+// hash the server reports for the module. Modules that fit in one chunk,
+// or servers without range support, fall back to a single stream.
+func (c *Client) DownloadModuleWithOptions(ctx context.Context, moduleID string, w io.Writer, options DownloadOptions, opts ...CallOption) (int64, error) {
+	if options.Parallelism <= 1 {
+		return c.DownloadModuleContext(ctx, moduleID, w, opts...)
+	}
+	if options.ChunkSize <= 0 {
+		options.ChunkSize = DefaultDownloadChunkSize
+	}
+	moduleID, err := c.resolveVersionRef(ctx, moduleID, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	path := "/modules/" + url.PathEscape(moduleID) + "/download"
+	size, ranges, err := c.probeDownload(ctx, path, opts)
+	if err != nil {
+		return 0, err
+	}
+	if !ranges || size <= options.ChunkSize {
+		return c.DownloadModuleContext(ctx, moduleID, w, opts...)
+	}
+
+	digest, pinned, err := parsePin(moduleID)
+	if err != nil {
+		return 0, err
+	}
+	if !pinned {
+		module, err := c.GetModuleContext(ctx, moduleID, opts...)
+		if err != nil {
+			return 0, err
+		}
+		digest = normalizeDigest(module.Hash)
+	}
+
+	h := sha256.New()
+	written, err := c.downloadRanges(ctx, path, size, options, io.MultiWriter(w, h), opts)
+	if err != nil {
+		return written, err
+	}
+
+	if digest != "" {
+		if got := hex.EncodeToString(h.Sum(nil)); got != digest {
+			return written, fmt.Errorf("%w: downloaded %s, got sha256:%s", ErrDigestMismatch, moduleID, got)
+		}
+	}
+	return written, nil
+}
+
+// probeDownload returns the size of a download and whether the server
+// accepts byte ranges for it
+func (c *Client) probeDownload(ctx context.Context, path string, opts []CallOption) (int64, bool, error) {
+	req := &request{
+		op:     "download",
+		method: http.MethodHead,
+		path:   path,
+	}
+
+	var size int64
+	var ranges bool
+	err := c.do(ctx, req, opts, func(resp *http.Response) error {
+		size = resp.ContentLength
+		ranges = resp.Header.Get("Accept-Ranges") == "bytes"
+		return nil
+	})
+	return size, ranges && size > 0, err
+}
+
+// downloadRanges fetches size bytes in chunks, options.Parallelism at a
+// time, and writes them to w in order
+func (c *Client) downloadRanges(ctx context.Context, path string, size int64, options DownloadOptions, w io.Writer, opts []CallOption) (int64, error) {
+	n := int((size + options.ChunkSize - 1) / options.ChunkSize)
+	chunks := make([]chan []byte, n)
+	for i := range chunks {
+		chunks[i] = make(chan []byte, 1)
+	}
+	// window bounds the chunks fetched ahead of the writer
+	window := make(chan struct{}, 2*options.Parallelism)
+
+	// cancel stops the fetchers if writing fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(options.Parallelism + 1)
+	g.Go(func() error {
+		for i := 0; i < n; i++ {
+			select {
+			case window <- struct{}{}:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+
+			i := i
+			start := int64(i) * options.ChunkSize
+			end := start + options.ChunkSize - 1
+			if end >= size {
+				end = size - 1
+			}
+			g.Go(func() error {
+				data, err := c.downloadRange(gctx, path, start, end, opts)
+				if err != nil {
+					return err
+				}
+				chunks[i] <- data
+				return nil
+			})
+		}
+		return nil
+	})
+
+	var written int64
+	var werr error
+	done := 0
+write:
+	for ; done < n; done++ {
+		select {
+		case data := <-chunks[done]:
+			m, err := w.Write(data)
+			written += int64(m)
+			if err != nil {
+				werr = fmt.Errorf("failed to download module: %w", err)
+				cancel()
+				break write
+			}
+			<-window
+		case <-gctx.Done():
+			// a failed fetch is reported by g.Wait
+			break write
+		}
+	}
+
+	err := g.Wait()
+	if werr != nil {
+		return written, werr
+	}
+	if err == nil && done < n {
+		err = gctx.Err()
+	}
+	return written, err
+}
+
+// downloadRange fetches the inclusive byte range [start, end]
+func (c *Client) downloadRange(ctx context.Context, path string, start, end int64, opts []CallOption) ([]byte, error) {
+	req := &request{
+		op:       "download",
+		method:   http.MethodGet,
+		path:     path,
+		header:   http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", start, end)}},
+		download: true,
+	}
+
+	var data []byte
+	err := c.do(ctx, req, opts, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusPartialContent {
+			return fmt.Errorf("failed to download module: server ignored range %d-%d", start, end)
+		}
+		want := end - start + 1
+		buf, err := io.ReadAll(io.LimitReader(throttle(ctx, resp.Body, c.downloadLimit), want+1))
+		if err != nil {
+			return fmt.Errorf("failed to download module: %w", err)
+		}
+		if int64(len(buf)) != want {
+			return fmt.Errorf("failed to download module: range %d-%d returned %d bytes", start, end, len(buf))
+		}
+		data = buf
+		return nil
+	})
+	return data, err
+}
@@ -0,0 +1,79 @@
+package wasmify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDownloadModuleWithOptionsReportsFailedChunk(t *testing.T) {
+	content := bytes.Repeat([]byte("wasm"), 64)
+	const chunkSize = 32
+	failStart := 3 * chunkSize
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/modules/mod-1":
+			writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod"})
+		case "/modules/mod-1/download":
+			w.Header().Set("Accept-Ranges", "bytes")
+			if r.Method == http.MethodHead {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				return
+			}
+			var start, end int
+			if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+				t.Errorf("got Range %q: %v", r.Header.Get("Range"), err)
+			}
+			if start == failStart {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"success":false,"error":"chunk unavailable"}`))
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[start : end+1])
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	var buf bytes.Buffer
+	written, err := c.DownloadModuleWithOptions(context.Background(), "mod-1", &buf, DownloadOptions{Parallelism: 2, ChunkSize: chunkSize})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got error %v, want the failed chunk's 500", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want the chunk failure rather than a cancellation", err)
+	}
+	if written > int64(failStart) || !bytes.Equal(buf.Bytes(), content[:written]) {
+		t.Errorf("got %d bytes written, want a prefix of at most %d", written, failStart)
+	}
+}
+
+func TestThrottledDownloadOutlastsTimeout(t *testing.T) {
+	content := bytes.Repeat([]byte{1}, 10000)
+	c := newTestClient(t, Config{Timeout: 100 * time.Millisecond, MaxDownloadBytesPerSec: 5000}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	})
+
+	var buf bytes.Buffer
+	start := time.Now()
+	n, err := c.DownloadModule("mod-1", &buf)
+	if err != nil {
+		t.Fatalf("DownloadModule: %v", err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("got %d bytes, want %d", n, len(content))
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("download took %v, want it paced to about a second", elapsed)
+	}
+}
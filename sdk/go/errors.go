@@ -0,0 +1,232 @@
+package wasmify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxErrorBodySize caps how much of an error response is read
+const maxErrorBodySize = 64 << 10
+
+// Error codes the server reports in the code field of error responses
+const (
+	codeConcurrencyLimit  = "concurrency_limit"
+	codeModuleRateLimited = "module_rate_limited"
+	codeExecutionTimeout  = "execution_timeout"
+)
+
+// ErrConcurrencyLimit is returned when an execution would exceed the
+// module's concurrency limit
+var ErrConcurrencyLimit = errors.New("module concurrency limit reached")
+
+// ErrModuleRateLimited is returned when an execution exceeds the rate limit
+// the module's owner set; APIError.RetryAfter says when to try again
+var ErrModuleRateLimited = errors.New("module rate limit exceeded")
+
+// ErrNetworkTimeout is matched by the error returned when a request timed
+// out before the server responded, so the module may never have run
+var ErrNetworkTimeout = errors.New("network timeout")
+
+// ErrExecutionTimeout is matched by the error returned when the server ran
+// the module but it exceeded its time budget
+var ErrExecutionTimeout = errors.New("execution timed out")
+
+// networkTimeoutError marks a timeout before response headers arrived. It
+// matches ErrNetworkTimeout and still unwraps to the underlying error, such
+// as context.DeadlineExceeded.
+type networkTimeoutError struct {
+	err error
+}
+
+func (e *networkTimeoutError) Error() string {
+	return e.err.Error()
+}
+
+func (e *networkTimeoutError) Is(target error) bool {
+	return target == ErrNetworkTimeout
+}
+
+func (e *networkTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// ExecutionTimeoutError reports an execution the runtime stopped for
+// exceeding its time budget. It matches ErrExecutionTimeout.
+type ExecutionTimeoutError struct {
+	// Elapsed is how long the module ran, as reported by the server
+	Elapsed time.Duration
+	// Message is the runtime's description of the failure
+	Message string
+}
+
+func (e *ExecutionTimeoutError) Error() string {
+	msg := fmt.Sprintf("execution timed out after %s", e.Elapsed)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *ExecutionTimeoutError) Is(target error) bool {
+	return target == ErrExecutionTimeout
+}
+
+// ErrModuleNotFound is matched by the error returned for a module that
+// does not exist
+var ErrModuleNotFound = errors.New("module not found")
+
+// ModuleNotFoundError reports a module ID the server does not know. It
+// matches ErrModuleNotFound and unwraps to the server's *APIError.
+type ModuleNotFoundError struct {
+	ModuleID string
+	Err      error
+}
+
+func (e *ModuleNotFoundError) Error() string {
+	return fmt.Sprintf("module %s not found", e.ModuleID)
+}
+
+func (e *ModuleNotFoundError) Is(target error) bool {
+	return target == ErrModuleNotFound
+}
+
+func (e *ModuleNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// moduleNotFound turns a 404 for moduleID into a *ModuleNotFoundError and
+// returns other errors unchanged
+func moduleNotFound(moduleID string, err error) error {
+	if IsNotFound(err) {
+		return &ModuleNotFoundError{ModuleID: moduleID, Err: err}
+	}
+	return err
+}
+
+// APIError is returned when the API responds with a non-success status
+type APIError struct {
+	// Op names the failed operation, e.g. "upload"
+	Op         string
+	StatusCode int
+	Status     string
+	// Message is the error reported by the server, if any
+	Message string
+	// Code is the machine-readable error code reported by the server
+	Code string
+	// ClockSkew is set on authentication failures when the local clock
+	// is far enough off the server's to be the likely cause
+	ClockSkew time.Duration
+	// RetryAfter is the delay the server asked for in its Retry-After
+	// header, if any
+	RetryAfter time.Duration
+
+	// args holds the server's argument validation details
+	args *ArgError
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s failed with status: %s", e.Op, e.Status)
+	switch {
+	case e.args != nil:
+		msg += ": " + e.args.Error()
+	case e.Message != "":
+		msg += ": " + e.Message
+	}
+	if e.ClockSkew != 0 {
+		msg += fmt.Sprintf(" (server clock differs from local clock by %s)", e.ClockSkew.Round(time.Second))
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %s)", e.RetryAfter.Round(time.Second))
+	}
+	return msg
+}
+
+// Unwrap maps well-known server error codes to sentinel errors so callers
+// can match them with errors.Is
+func (e *APIError) Unwrap() error {
+	switch e.Code {
+	case codeConcurrencyLimit:
+		return ErrConcurrencyLimit
+	case codeModuleRateLimited:
+		return ErrModuleRateLimited
+	case codeMemoryLimitExceeded:
+		return ErrMemoryLimitExceeded
+	case codeExecutionTimeout:
+		return ErrExecutionTimeout
+	case codeInvalidArgs:
+		if e.args != nil {
+			return e.args
+		}
+	}
+	return nil
+}
+
+// IsNotFound reports whether err is an *APIError for a 404 response
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is an *APIError for a 401 response,
+// meaning the API key is missing, invalid or expired
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsRateLimited reports whether err is an *APIError for a 429 response.
+// Such responses are retried after their Retry-After delay when retries
+// are enabled, so this means the retries ran out or were disabled.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+func hasStatus(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == code
+}
+
+// newAPIError builds an APIError from a non-success response, reading the
+// server's error message from the body when present
+func newAPIError(op string, resp *http.Response, codec Codec) *APIError {
+	apiErr := &APIError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if env, err := decodeEnvelope(codec, body); err == nil {
+		apiErr.Message = env.err
+		apiErr.Code = env.code
+		if env.code == codeInvalidArgs {
+			args := &ArgError{Index: -1}
+			if env.details(args) == nil && len(args.Expected) > 0 {
+				apiErr.args = args
+			}
+		}
+	}
+
+	return apiErr
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date; unparseable or past values yield zero
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
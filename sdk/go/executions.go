@@ -0,0 +1,93 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Execution statuses reported in the execution history
+const (
+	ExecutionSucceeded = "success"
+	ExecutionFailed    = "failed"
+)
+
+// ExecutionRecord is one past execution of a module
+type ExecutionRecord struct {
+	ID           string    `json:"id"`
+	ModuleID     string    `json:"moduleId"`
+	FunctionName string    `json:"functionName"`
+	Status       string    `json:"status"`
+	Success      bool      `json:"success"`
+	Error        string    `json:"error,omitempty"`
+	StartedAt    time.Time `json:"startedAt"`
+	// Duration is the execution time in milliseconds
+	Duration float64 `json:"duration"`
+	// Caller identifies the API key or user that ran the execution
+	Caller string `json:"caller,omitempty"`
+}
+
+// ExecutionListOptions filters and paginates ListExecutions
+type ExecutionListOptions struct {
+	// Since and Until bound the start time of the executions returned;
+	// zero values leave that side open
+	Since time.Time
+	Until time.Time
+	// Status keeps only executions with this status, e.g. ExecutionFailed
+	Status string
+	// Limit caps the page size; zero uses the server default
+	Limit int
+	// Cursor continues from ExecutionPage.NextCursor of a previous page
+	Cursor string
+}
+
+func (o ExecutionListOptions) query() url.Values {
+	q := url.Values{}
+	if !o.Since.IsZero() {
+		q.Set("since", o.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if !o.Until.IsZero() {
+		q.Set("until", o.Until.UTC().Format(time.RFC3339Nano))
+	}
+	if o.Status != "" {
+		q.Set("status", o.Status)
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	return q
+}
+
+// ExecutionPage is one page of execution history
+type ExecutionPage struct {
+	Executions []ExecutionRecord `json:"executions"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ListExecutions returns a page of a module's execution history, most
+// recent first
+func (c *Client) ListExecutions(ctx context.Context, moduleID string, options ExecutionListOptions, opts ...CallOption) (*ExecutionPage, error) {
+	path := "/modules/" + url.PathEscape(moduleID) + "/executions"
+	if q := options.query(); len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	req := &request{
+		op:     "list executions",
+		method: http.MethodGet,
+		path:   path,
+	}
+
+	var page ExecutionPage
+	if err := c.doJSON(ctx, req, opts, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
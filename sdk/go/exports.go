@@ -0,0 +1,135 @@
+package wasmify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownFunction is matched by the error LookupExport returns when a
+// module has no export of the requested name
+var ErrUnknownFunction = errors.New("function not exported")
+
+// UnknownFunctionError reports a missing export. Suggestion is the closest
+// exported name, if any is close enough to be a likely typo.
+type UnknownFunctionError struct {
+	Name       string
+	Suggestion string
+}
+
+func (e *UnknownFunctionError) Error() string {
+	msg := fmt.Sprintf("%v: %q", ErrUnknownFunction, e.Name)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf("; did you mean %q?", e.Suggestion)
+	}
+	return msg
+}
+
+func (e *UnknownFunctionError) Unwrap() error {
+	return ErrUnknownFunction
+}
+
+// ExportedFunction is a function a module exports, with its WebAssembly
+// signature. Params and Results hold value type names such as "i32".
+type ExportedFunction struct {
+	Name    string
+	Params  []string
+	Results []string
+}
+
+// GetModuleExports downloads a module and returns the functions it
+// exports, in export order
+func (c *Client) GetModuleExports(ctx context.Context, moduleID string, opts ...CallOption) ([]ExportedFunction, error) {
+	var buf bytes.Buffer
+	if _, err := c.DownloadModuleContext(ctx, moduleID, &buf, opts...); err != nil {
+		return nil, err
+	}
+
+	bin, err := parseWasm(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return bin.exportedFunctions()
+}
+
+func (b *wasmBinary) exportedFunctions() ([]ExportedFunction, error) {
+	var funcs []ExportedFunction
+	for _, exp := range b.exports {
+		if exp.kind != externFunc {
+			continue
+		}
+		ft, ok := b.funcType(exp.index)
+		if !ok {
+			return nil, fmt.Errorf("%w: export %q refers to unknown function %d", errMalformedWasm, exp.name, exp.index)
+		}
+		funcs = append(funcs, ExportedFunction{
+			Name:    exp.name,
+			Params:  ft.params,
+			Results: ft.results,
+		})
+	}
+	return funcs, nil
+}
+
+// LookupExport finds the export called name among funcs, as returned by
+// GetModuleExports. When there is none it returns an
+// *UnknownFunctionError suggesting the closest name.
+func LookupExport(funcs []ExportedFunction, name string) (ExportedFunction, error) {
+	for _, fn := range funcs {
+		if fn.Name == name {
+			return fn, nil
+		}
+	}
+	return ExportedFunction{}, &UnknownFunctionError{Name: name, Suggestion: suggestExport(funcs, name)}
+}
+
+// suggestExport returns the export name nearest to name by edit distance,
+// or "" when nothing is within a typo's reach
+func suggestExport(funcs []ExportedFunction, name string) string {
+	limit := len([]rune(name)) / 3
+	if limit < 2 {
+		limit = 2
+	}
+
+	best, bestDist := "", limit+1
+	for _, fn := range funcs {
+		if d := editDistance(name, fn.Name); d < bestDist {
+			best, bestDist = fn.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
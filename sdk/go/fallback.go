@@ -0,0 +1,66 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ExecuteWithFallback executes a module function remotely and, if the
+// server cannot be reached, runs it from the local file at wasmFilePath
+// instead. Only connectivity failures trigger the fallback: a call
+// rejected by the server or a function that fails is reported as usual.
+// The local run is bounded by ctx and honours the MaxExecutionTime,
+// memory limits and RandomSeed of config. A call naming no function
+// cannot fall back, as the default function is only known to the server.
+// Result.Local tells which path served the call.
+func (c *Client) ExecuteWithFallback(ctx context.Context, wasmFilePath, moduleID, functionName string, args []interface{}, config ExecutionConfig, opts ...CallOption) (*ExecutionResult, error) {
+	result, err := c.Execute(ctx, moduleID, functionName, args, config, opts...)
+	if err == nil || ctx.Err() != nil || !isConnectivityError(err) {
+		return result, err
+	}
+	if functionName == "" {
+		return nil, fmt.Errorf("cannot run locally without a function name, as the server is unreachable to name the default: %w", err)
+	}
+
+	cfg, lerr := config.withDefaults(c.config.DefaultExecutionConfig).localConfig()
+	if lerr != nil {
+		return nil, lerr
+	}
+	return executeLocal(ctx, wasmFilePath, functionName, args, cfg)
+}
+
+// localConfig maps the limits of an execution config onto a local run
+func (c ExecutionConfig) localConfig() (LocalConfig, error) {
+	cfg := LocalConfig{Timeout: c.MaxExecutionTime, RandomSeed: c.RandomSeed}
+	if c.MemoryMax > 0 || c.MemoryLimitBytes > 0 {
+		_, max, err := c.memoryPages()
+		if err != nil {
+			return LocalConfig{}, err
+		}
+		cfg.MaxMemoryBytes = int64(max) * wasmPageSize
+	}
+	return cfg, nil
+}
+
+// isConnectivityError reports whether err means the server was not
+// reached, as opposed to the server answering with a failure. Gateway
+// errors count as unreachable since they come from a proxy in front of
+// the server.
+func isConnectivityError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package wasmify
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// spinModule is (module (func (export "spin") (loop (br 0)))), which
+// never returns
+var spinModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type: () -> ()
+	0x03, 0x02, 0x01, 0x00, // func 0 has type 0
+	0x07, 0x08, 0x01, 0x04, 's', 'p', 'i', 'n', 0x00, 0x00, // export "spin"
+	0x0a, 0x09, 0x01, 0x07, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b, // loop br 0
+}
+
+// unreachableClient returns a client whose server has gone away
+func unreachableClient(t *testing.T) *Client {
+	t.Helper()
+	srv := httptest.NewServer(nil)
+	srv.Close()
+	return NewClient(Config{APIURL: srv.URL})
+}
+
+func TestExecuteWithFallbackRunsLocally(t *testing.T) {
+	c := unreachableClient(t)
+	result, err := c.ExecuteWithFallback(context.Background(), writeModule(t, identityI64), "mod-1", "id", []interface{}{5}, ExecutionConfig{})
+	if err != nil {
+		t.Fatalf("ExecuteWithFallback: %v", err)
+	}
+	if !result.Local || result.Result != int64(5) {
+		t.Errorf("got result %+v, want 5 from a local run", result)
+	}
+}
+
+func TestExecuteWithFallbackHonoursLimits(t *testing.T) {
+	c := unreachableClient(t)
+	path := writeModule(t, spinModule)
+
+	start := time.Now()
+	result, err := c.ExecuteWithFallback(context.Background(), path, "mod-1", "spin", nil, ExecutionConfig{MaxExecutionTime: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("ExecuteWithFallback: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, ErrExecutionTimeout.Error()) {
+		t.Errorf("got result %+v, want a local timeout", result)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if result, err := c.ExecuteWithFallback(ctx, path, "mod-1", "spin", nil, ExecutionConfig{}); err == nil && result.Success {
+		t.Error("got a successful run past the context deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("fallback runs took %v, want them stopped by their limits", elapsed)
+	}
+}
+
+func TestExecuteWithFallbackNeedsFunctionName(t *testing.T) {
+	c := unreachableClient(t)
+	_, err := c.ExecuteWithFallback(context.Background(), writeModule(t, identityI64), "mod-1", "", nil, ExecutionConfig{})
+	if err == nil || !strings.Contains(err.Error(), "without a function name") {
+		t.Errorf("got error %v, want one about the missing function name", err)
+	}
+}
@@ -0,0 +1,92 @@
+package wasmify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightGroup runs one call per key at a time and shares its result with
+// every caller that asks for the key meanwhile. No caller's context
+// governs the call: it runs detached, keeping only the values of the
+// caller that started it, and is cancelled once every caller waiting on
+// it has gone away. Each caller stops waiting when its own context is
+// done.
+//
+// singleflight.Group cannot do the last part. DoChan on a detached
+// context stops a caller waiting, but the group does not count waiters,
+// so nothing would cancel an upload that every caller has abandoned; it
+// would run to the end and hold the key for whoever asks next.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	value interface{}
+	err   error
+}
+
+// do returns the result of fn for key, joining a call already in flight
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	f, ok := g.calls[key]
+	if !ok {
+		fctx, cancel := context.WithCancel(detachedContext{ctx})
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = f
+		go func() {
+			defer close(f.done)
+			defer cancel()
+			f.value, f.err = fn(fctx)
+			g.forget(key, f)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// later callers start afresh rather than join a cancelled call
+			g.forgetLocked(key, f)
+			f.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.forgetLocked(key, f)
+}
+
+func (g *flightGroup) forgetLocked(key string, f *flight) {
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
+
+// detachedContext keeps the values of its parent but none of its deadline
+// or cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
@@ -0,0 +1,86 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlightGroupOutlivesFirstCaller(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		select {
+		case <-release:
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := g.do(first, "key", fn)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan interface{}, 1)
+	go func() {
+		v, err := g.do(context.Background(), "key", func(context.Context) (interface{}, error) {
+			t.Error("second caller started its own call")
+			return nil, nil
+		})
+		if err != nil {
+			t.Errorf("second caller: %v", err)
+		}
+		second <- v
+	}()
+	// let the second caller join before the first leaves
+	for {
+		g.mu.Lock()
+		n := g.calls["key"].waiters
+		g.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v for the first caller, want context.Canceled", err)
+	}
+	close(release)
+	if v := <-second; v != "done" {
+		t.Errorf("got %v for the second caller, want done", v)
+	}
+}
+
+func TestFlightGroupCancelsWhenAbandoned(t *testing.T) {
+	var g flightGroup
+	cancelled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := g.do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("call kept running after every caller left")
+	}
+}
@@ -3,11 +3,16 @@ module github.com/wasmify/sdk-go
 go 1.19
 
 require (
+	github.com/shamaton/msgpack/v2 v2.1.1
 	github.com/stretchr/testify v1.8.4
+	github.com/tetratelabs/wazero v1.6.0
+	golang.org/x/oauth2 v0.26.0
+	golang.org/x/sync v0.10.0
+	golang.org/x/time v0.10.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
\ No newline at end of file
+)
@@ -0,0 +1,45 @@
+package wasmify
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decodeContentEncoding replaces a gzip-encoded response body with its
+// decompressed form. The standard transport does this on its own unless
+// Accept-Encoding was set explicitly or a custom transport is in use, in
+// which case the body would otherwise reach the decoders compressed.
+func decodeContentEncoding(resp *http.Response) error {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+	default:
+		return nil
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decompress response: %w", err)
+	}
+	resp.Body = &gzipBody{zr: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// gzipBody reads a decompressed body and closes the underlying one
+type gzipBody struct {
+	zr   *gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) { return b.zr.Read(p) }
+
+func (b *gzipBody) Close() error {
+	b.zr.Close()
+	return b.body.Close()
+}
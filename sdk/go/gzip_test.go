@@ -0,0 +1,47 @@
+package wasmify
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// acceptGzip asks for gzip explicitly, which stops the standard transport
+// from decompressing the response itself
+type acceptGzip struct{}
+
+func (acceptGzip) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestListModulesDecodesGzip(t *testing.T) {
+	config := Config{HTTPClient: &http.Client{Transport: acceptGzip{}}}
+	c := newTestClient(t, config, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("got Accept-Encoding %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		json.NewEncoder(zw).Encode(map[string]interface{}{
+			"success": true,
+			"data": []map[string]interface{}{
+				{"id": "mod-1", "name": "first"},
+				{"id": "mod-2", "name": "second"},
+			},
+		})
+	})
+
+	modules, err := c.ListModules()
+	if err != nil {
+		t.Fatalf("ListModules: %v", err)
+	}
+	if len(modules) != 2 || modules[0].ID != "mod-1" || modules[1].Name != "second" {
+		t.Errorf("got modules %+v, want mod-1 and mod-2", modules)
+	}
+}
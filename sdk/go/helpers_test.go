@@ -0,0 +1,27 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient starts a server running handler and returns a client
+// pointed at it
+func newTestClient(t *testing.T, config Config, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	config.APIURL = srv.URL
+	return NewClient(config)
+}
+
+// writeData writes v in the API's success envelope
+func writeData(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": v}); err != nil {
+		t.Errorf("failed to write response: %v", err)
+	}
+}
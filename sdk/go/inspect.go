@@ -0,0 +1,99 @@
+package wasmify
+
+// Import kinds reported in ModuleImport.Kind
+const (
+	ImportFunction = "function"
+	ImportTable    = "table"
+	ImportMemory   = "memory"
+	ImportGlobal   = "global"
+	ImportTag      = "tag"
+)
+
+var importKinds = map[byte]string{
+	externFunc:   ImportFunction,
+	externTable:  ImportTable,
+	externMemory: ImportMemory,
+	externGlobal: ImportGlobal,
+	externTag:    ImportTag,
+}
+
+// ModuleImport is something a module needs its host to provide
+type ModuleImport struct {
+	Module string
+	Name   string
+	// Kind is ImportFunction, ImportMemory or another Import constant
+	Kind string
+	// Params and Results are the signature of a function import
+	Params  []string
+	Results []string
+}
+
+// MemoryRequirement is the size range of a module's linear memory, in
+// 64KiB pages
+type MemoryRequirement struct {
+	MinPages uint64
+	// MaxPages is only meaningful when HasMax is set; without it memory
+	// may grow to the runtime's limit
+	MaxPages uint64
+	HasMax   bool
+	// Imported reports memory the host must provide rather than memory
+	// the module defines
+	Imported bool
+}
+
+// MinBytes is the memory the module needs before it runs at all
+func (m MemoryRequirement) MinBytes() int64 {
+	return int64(m.MinPages) * wasmPageSize
+}
+
+// ModuleInfo describes a module's interface as read from its binary
+type ModuleInfo struct {
+	// Size is the size of the binary in bytes
+	Size int64
+	// Exports lists the exported functions in export order
+	Exports  []ExportedFunction
+	Imports  []ModuleImport
+	Memories []MemoryRequirement
+}
+
+// Export finds an exported function by name, failing like LookupExport
+// when there is none
+func (m *ModuleInfo) Export(name string) (ExportedFunction, error) {
+	return LookupExport(m.Exports, name)
+}
+
+// InspectModule reads the exports, imports and memory requirements of a
+// .wasm file without running it, e.g. to check a function exists before
+// executing it
+func InspectModule(wasmFilePath string) (*ModuleInfo, error) {
+	bin, err := parseWasmFile(wasmFilePath)
+	if err != nil {
+		return nil, err
+	}
+	exports, err := bin.exportedFunctions()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ModuleInfo{Size: bin.size, Exports: exports}
+	for _, imp := range bin.imports {
+		mi := ModuleImport{Module: imp.module, Name: imp.name, Kind: importKinds[imp.kind]}
+		switch imp.kind {
+		case externFunc:
+			if ft, ok := bin.typeAt(imp.typeIndex); ok {
+				mi.Params, mi.Results = ft.params, ft.results
+			}
+		case externMemory:
+			info.Memories = append(info.Memories, memoryRequirement(imp.limits, true))
+		}
+		info.Imports = append(info.Imports, mi)
+	}
+	for _, l := range bin.memories {
+		info.Memories = append(info.Memories, memoryRequirement(l, false))
+	}
+	return info, nil
+}
+
+func memoryRequirement(l wasmLimits, imported bool) MemoryRequirement {
+	return MemoryRequirement{MinPages: l.min, MaxPages: l.max, HasMax: l.hasMax, Imported: imported}
+}
@@ -0,0 +1,62 @@
+package wasmify
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultKeepAliveInterval is the ping interval used when KeepAlivePing is
+// set without KeepAliveInterval
+const DefaultKeepAliveInterval = 30 * time.Second
+
+// newTransport returns the base transport for config: the default one,
+// unless TCP keepalive needs tuning
+func newTransport(config Config) http.RoundTripper {
+	if config.TCPKeepAlive == 0 {
+		return http.DefaultTransport
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: config.TCPKeepAlive,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// keepAlive pings the API whenever the client has been idle for interval,
+// until the client is shut down or closed
+func (c *Client) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.state.closing:
+			return
+		case <-ticker.C:
+		}
+
+		if time.Since(time.Unix(0, c.lastRequest.Load())) < interval {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		_ = c.ping(ctx)
+		cancel()
+	}
+}
+
+// ping sends a single lightweight request to keep a connection warm
+func (c *Client) ping(ctx context.Context) error {
+	req := &request{
+		op:     "keepalive",
+		method: http.MethodGet,
+		path:   "/health",
+	}
+	return c.do(ctx, req, []CallOption{WithRetryPolicy(RetryPolicy{})}, func(*http.Response) error {
+		return nil
+	})
+}
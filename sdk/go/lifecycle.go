@@ -0,0 +1,116 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientClosed is returned for requests started after Shutdown or Close
+var ErrClientClosed = errors.New("client is closed")
+
+// lifecycle tracks in-flight requests so the client can drain them on
+// shutdown
+type lifecycle struct {
+	closed   bool
+	inflight int
+	// closing is closed once Shutdown or Close has been called
+	closing chan struct{}
+	// drained is closed once shutdown has begun and no request is in flight
+	drained chan struct{}
+	// abort is closed by Close to cancel in-flight requests
+	abort chan struct{}
+}
+
+// Shutdown stops the client from accepting new requests, waits for in-flight
+// requests to finish, and then closes idle connections. If ctx expires first
+// Shutdown returns its error and in-flight requests keep running. Requests
+// started after Shutdown fail with ErrClientClosed.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.beginClose()
+	drained := c.state.drained
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-drained:
+	}
+
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// Close stops the client immediately: new requests fail with
+// ErrClientClosed, in-flight requests are cancelled, and idle connections
+// are closed. Use Shutdown to let in-flight requests finish instead.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.beginClose()
+	select {
+	case <-c.state.abort:
+	default:
+		close(c.state.abort)
+	}
+	c.mu.Unlock()
+
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// beginClose marks the client closed; c.mu must be held
+func (c *Client) beginClose() {
+	if c.state.closed {
+		return
+	}
+	c.state.closed = true
+	close(c.state.closing)
+	c.state.drained = make(chan struct{})
+	if c.state.inflight == 0 {
+		close(c.state.drained)
+	}
+}
+
+// aborted reports whether Close has cancelled in-flight requests
+func (c *Client) aborted() bool {
+	select {
+	case <-c.state.abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire registers a new in-flight request. The returned context is
+// cancelled when the request is released or the client is closed, and
+// release must be called once the request completes.
+func (c *Client) acquire(ctx context.Context) (context.Context, func(), error) {
+	c.mu.Lock()
+	if c.state.closed {
+		c.mu.Unlock()
+		return nil, nil, ErrClientClosed
+	}
+	c.state.inflight++
+	abort := c.state.abort
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	release := func() {
+		cancel()
+		c.mu.Lock()
+		c.state.inflight--
+		if c.state.closed && c.state.inflight == 0 {
+			close(c.state.drained)
+		}
+		c.mu.Unlock()
+	}
+	return ctx, release, nil
+}
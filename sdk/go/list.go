@@ -0,0 +1,286 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Default caps on automatic pagination, generous enough for real catalogs
+// while still stopping a runaway loop
+const (
+	DefaultMaxPages = 1000
+	DefaultMaxItems = 100000
+)
+
+// ErrMoreResults is returned by the module iterator and ListAllModules when
+// a pagination cap was reached before the listing was exhausted. The
+// modules collected so far are still valid.
+var ErrMoreResults = errors.New("pagination limit reached; more results exist")
+
+// Module deployment states accepted by ListOptions.DeploymentState
+const (
+	ModuleDeployed     = "deployed"
+	ModuleUndeployed   = "undeployed"
+	ModuleDeployFailed = "failed"
+)
+
+// Sort keys and orders accepted by ListOptions
+const (
+	SortByCreatedAt = "createdAt"
+	SortByName      = "name"
+	SortBySize      = "size"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// ListOptions controls paginated module listing
+type ListOptions struct {
+	// Limit caps the page size; zero uses the server default
+	Limit int
+	// Cursor continues from ModulePage.NextCursor of a previous page
+	Cursor string
+	// Offset skips that many modules, for servers that page by offset;
+	// it is ignored when Cursor is set
+	Offset int
+	// DeploymentState keeps only modules in this state, e.g.
+	// ModuleUndeployed
+	DeploymentState string
+	// Language and IsPublic keep only modules with that language or
+	// visibility; a nil IsPublic matches both
+	Language string
+	IsPublic *bool
+	// SortBy orders the listing by a field such as SortByCreatedAt, the
+	// default, in Order (OrderAsc or OrderDesc); ties are broken by ID.
+	// An empty Order uses the server default.
+	SortBy string
+	Order  string
+
+	// MaxPages and MaxItems cap how far the iterator walks; zero uses
+	// DefaultMaxPages and DefaultMaxItems. They do not affect
+	// ListModulesPaged.
+	MaxPages int
+	MaxItems int
+}
+
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	} else if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.DeploymentState != "" {
+		q.Set("deploymentState", o.DeploymentState)
+	}
+	if o.Language != "" {
+		q.Set("language", o.Language)
+	}
+	if o.IsPublic != nil {
+		q.Set("isPublic", strconv.FormatBool(*o.IsPublic))
+	}
+	// the ID tiebreaker keeps modules with equal sort keys in one order
+	// from page to page
+	sortBy := o.SortBy
+	if sortBy == "" {
+		sortBy = SortByCreatedAt
+	}
+	q.Set("sortBy", sortBy)
+	q.Set("thenBy", "id")
+	if o.Order != "" {
+		q.Set("order", o.Order)
+	}
+	return q
+}
+
+func (o ListOptions) maxPages() int {
+	if o.MaxPages > 0 {
+		return o.MaxPages
+	}
+	return DefaultMaxPages
+}
+
+func (o ListOptions) maxItems() int {
+	if o.MaxItems > 0 {
+		return o.MaxItems
+	}
+	return DefaultMaxItems
+}
+
+// ModulePage is one page of modules
+type ModulePage struct {
+	Modules []*WasmModule
+	// NextCursor is empty on the last page
+	NextCursor string
+	// Total is the number of modules matching the listing, or zero if
+	// the server did not report it
+	Total int
+}
+
+// ListModulesPaged returns a single page of modules. Unless SortBy says
+// otherwise, modules are ordered by creation time and then ID, and both
+// keys are sent so the order never rests on a server default. Cursors
+// are opaque positions in that order, so modules added or removed between
+// page fetches neither shift later pages nor cause repeats; each module
+// present for the whole walk is returned exactly once. Offsets give no
+// such guarantee, and a module inserted before the offset shifts the rest
+// of the listing.
+func (c *Client) ListModulesPaged(ctx context.Context, options ListOptions, opts ...CallOption) (*ModulePage, error) {
+	path := "/modules"
+	if q := options.query(); len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	req := &request{
+		op:     "list",
+		method: http.MethodGet,
+		path:   path,
+	}
+
+	var data struct {
+		Modules    []moduleRecord `json:"modules"`
+		NextCursor string         `json:"nextCursor"`
+		Total      int            `json:"total"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
+	}
+
+	page := &ModulePage{
+		Modules:    make([]*WasmModule, len(data.Modules)),
+		NextCursor: data.NextCursor,
+		Total:      data.Total,
+	}
+	for i := range data.Modules {
+		page.Modules[i] = data.Modules[i].toModule()
+	}
+	return page, nil
+}
+
+// ModuleIterator walks every page of a module listing. Call Next until it
+// returns false, then check Err.
+type ModuleIterator struct {
+	c       *Client
+	ctx     context.Context
+	options ListOptions
+	opts    []CallOption
+
+	page    []*WasmModule
+	cursor  string
+	offset  int
+	seen    map[string]struct{}
+	more    bool
+	pages   int
+	items   int
+	started bool
+
+	current *WasmModule
+	err     error
+}
+
+// Modules returns an iterator over all modules, starting at
+// options.Cursor or options.Offset. It stops with ErrMoreResults once
+// MaxPages pages have been fetched or MaxItems modules returned and more
+// remain. A module repeated by a shifting offset listing is returned
+// only once.
+func (c *Client) Modules(ctx context.Context, options ListOptions, opts ...CallOption) *ModuleIterator {
+	return &ModuleIterator{
+		c:       c,
+		ctx:     ctx,
+		options: options,
+		opts:    opts,
+		cursor:  options.Cursor,
+		offset:  options.Offset,
+		seen:    make(map[string]struct{}),
+	}
+}
+
+// Next advances to the next module, fetching the next page when needed
+func (it *ModuleIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for {
+		if !it.fill() {
+			return false
+		}
+
+		m := it.page[0]
+		it.page = it.page[1:]
+		if m.ID != "" {
+			if _, ok := it.seen[m.ID]; ok {
+				continue
+			}
+			it.seen[m.ID] = struct{}{}
+		}
+
+		if it.items >= it.options.maxItems() {
+			it.err = ErrMoreResults
+			return false
+		}
+		it.current = m
+		it.items++
+		return true
+	}
+}
+
+// fill fetches pages until one has modules, reporting false when the
+// listing is exhausted or failed
+func (it *ModuleIterator) fill() bool {
+	for len(it.page) == 0 {
+		if it.started && !it.more {
+			return false
+		}
+		if it.pages >= it.options.maxPages() {
+			it.err = ErrMoreResults
+			return false
+		}
+
+		options := it.options
+		options.Cursor = it.cursor
+		options.Offset = it.offset
+		page, err := it.c.ListModulesPaged(it.ctx, options, it.opts...)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.started = true
+		it.pages++
+		it.page = page.Modules
+		it.cursor = page.NextCursor
+		it.offset += len(page.Modules)
+		// without a cursor, keep paging by offset while the reported
+		// total says modules remain
+		it.more = it.cursor != "" || (len(page.Modules) > 0 && it.offset < page.Total)
+	}
+	return true
+}
+
+// Module returns the module Next advanced to
+func (it *ModuleIterator) Module() *WasmModule {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ModuleIterator) Err() error {
+	return it.err
+}
+
+// ListAllModules collects every module by walking all pages. If a
+// pagination cap is reached it returns the modules collected so far along
+// with ErrMoreResults.
+func (c *Client) ListAllModules(ctx context.Context, options ListOptions, opts ...CallOption) ([]*WasmModule, error) {
+	var modules []*WasmModule
+	it := c.Modules(ctx, options, opts...)
+	for it.Next() {
+		modules = append(modules, it.Module())
+	}
+	return modules, it.Err()
+}
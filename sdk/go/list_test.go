@@ -0,0 +1,91 @@
+package wasmify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// catalog is a module listing server that pages by cursor in creation
+// time and ID order
+type catalog struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	modules []moduleRecord
+	// onPage runs after each page is served, e.g. to insert modules
+	onPage func(c *catalog, page int)
+	pages  int
+}
+
+func (c *catalog) add(id, createdAt string) {
+	c.modules = append(c.modules, moduleRecord{ID: id, Name: id, CreatedAt: createdAt})
+	sort.Slice(c.modules, func(i, j int) bool {
+		return sortKey(c.modules[i]) < sortKey(c.modules[j])
+	})
+}
+
+func sortKey(m moduleRecord) string { return m.CreatedAt + "|" + m.ID }
+
+func (c *catalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("sortBy") != SortByCreatedAt || q.Get("thenBy") != "id" {
+		c.t.Errorf("got sortBy=%q thenBy=%q, want createdAt then id", q.Get("sortBy"), q.Get("thenBy"))
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var page []moduleRecord
+	for _, m := range c.modules {
+		if sortKey(m) > q.Get("cursor") && len(page) < limit {
+			page = append(page, m)
+		}
+	}
+	next := ""
+	if len(page) == limit && sortKey(page[len(page)-1]) != sortKey(c.modules[len(c.modules)-1]) {
+		next = sortKey(page[len(page)-1])
+	}
+	writeData(c.t, w, map[string]interface{}{"modules": page, "nextCursor": next})
+
+	c.pages++
+	if c.onPage != nil {
+		c.onPage(c, c.pages)
+	}
+}
+
+func TestModulesStableAcrossInserts(t *testing.T) {
+	cat := &catalog{t: t}
+	for i := 1; i <= 6; i++ {
+		cat.add(fmt.Sprintf("mod-%d", i), fmt.Sprintf("2024-01-0%d", i))
+	}
+	// a module sharing a creation time is ordered by ID
+	cat.add("mod-3b", "2024-01-03")
+	cat.onPage = func(c *catalog, page int) {
+		if page == 1 {
+			// one module before the cursor and one past the end
+			c.add("mod-early", "2023-12-31")
+			c.add("mod-late", "2024-02-01")
+		}
+	}
+	c := newTestClient(t, Config{}, cat.ServeHTTP)
+
+	var got []string
+	it := c.Modules(context.Background(), ListOptions{Limit: 2})
+	for it.Next() {
+		got = append(got, it.Module().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Modules: %v", err)
+	}
+
+	want := "mod-1 mod-2 mod-3 mod-3b mod-4 mod-5 mod-6 mod-late"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got modules %v, want %s", got, want)
+	}
+}
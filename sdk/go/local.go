@@ -0,0 +1,269 @@
+package wasmify
+
+import (
+	"context"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// LocalConfig sets up the WASI environment of a local execution. The zero
+// value gives the module no environment, no files and discarded output.
+// wazero has no fuel metering, so there is no fuel limit: Timeout bounds
+// runaway modules instead, by wall-clock time rather than instructions.
+type LocalConfig struct {
+	// Env holds the environment variables the module sees
+	Env map[string]string
+	// PreopenDirs maps a path inside the module to the host directory
+	// mounted there, e.g. {"/config": "./testdata/config"}
+	PreopenDirs map[string]string
+	// Args are the command-line arguments, the first being the program
+	// name by convention
+	Args []string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// RandomSeed makes WASI random_get return the same bytes on every run
+	// with that seed, like ExecutionConfig.RandomSeed. When nil the module
+	// gets real entropy from crypto/rand.
+	RandomSeed *uint64
+
+	// MaxMemoryBytes caps the module's linear memory, rounded down to
+	// whole 64KiB pages. Growing past it fails inside the module, which
+	// usually traps. Failures with every page in use report
+	// ErrMemoryLimitExceeded; an allocator that gives up short of the cap,
+	// growing in large steps, reports only the module's own error.
+	MaxMemoryBytes int64
+	// Timeout stops the module once it has run this long
+	Timeout time.Duration
+}
+
+// moduleConfig translates cfg into the wazero module config
+func (cfg LocalConfig) moduleConfig() wazero.ModuleConfig {
+	mc := wazero.NewModuleConfig().WithArgs(cfg.Args...)
+
+	keys := make([]string, 0, len(cfg.Env))
+	for k := range cfg.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		mc = mc.WithEnv(k, cfg.Env[k])
+	}
+
+	if len(cfg.PreopenDirs) > 0 {
+		fs := wazero.NewFSConfig()
+		for guest, host := range cfg.PreopenDirs {
+			fs = fs.WithDirMount(host, guest)
+		}
+		mc = mc.WithFSConfig(fs)
+	}
+
+	if cfg.Stdin != nil {
+		mc = mc.WithStdin(cfg.Stdin)
+	}
+	if cfg.Stdout != nil {
+		mc = mc.WithStdout(cfg.Stdout)
+	}
+	if cfg.Stderr != nil {
+		mc = mc.WithStderr(cfg.Stderr)
+	}
+
+	// wazero's own source is deterministic, so entropy must be asked for
+	if cfg.RandomSeed != nil {
+		mc = mc.WithRandSource(rand.New(rand.NewSource(int64(*cfg.RandomSeed))))
+	} else {
+		mc = mc.WithRandSource(crand.Reader)
+	}
+	return mc
+}
+
+// executeLocal compiles and instantiates the module at path with wazero
+// and calls functionName with args
+func executeLocal(ctx context.Context, path, functionName string, args []interface{}, cfg LocalConfig) (*ExecutionResult, error) {
+	bin, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module: %w", err)
+	}
+	if cfg.MaxMemoryBytes < 0 || (cfg.MaxMemoryBytes > 0 && cfg.MaxMemoryBytes < wasmPageSize) {
+		return nil, fmt.Errorf("invalid MaxMemoryBytes %d: must be at least one 64KiB page", cfg.MaxMemoryBytes)
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("invalid Timeout %v: must not be negative", cfg.Timeout)
+	}
+	for guest, host := range cfg.PreopenDirs {
+		if info, err := os.Stat(host); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("invalid preopen dir %q for %q: not a directory", host, guest)
+		}
+	}
+
+	parsed, err := parseWasm(bin)
+	if err != nil {
+		return nil, err
+	}
+	funcs, err := parsed.exportedFunctions()
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCall(funcs, functionName, args); err != nil {
+		return nil, err
+	}
+
+	limitPages := uint64(cfg.MaxMemoryBytes / wasmPageSize)
+	for _, m := range parsed.memories {
+		if limitPages > 0 && m.min > limitPages {
+			return &ExecutionResult{
+				Error: fmt.Sprintf("%v: module needs %d bytes of memory, limit is %d", ErrMemoryLimitExceeded, m.min*wasmPageSize, limitPages*wasmPageSize),
+				Local: true,
+			}, nil
+		}
+	}
+
+	rc := wazero.NewRuntimeConfig()
+	if limitPages > 0 {
+		rc = rc.WithMemoryLimitPages(uint32(limitPages))
+	}
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	if ctx.Done() != nil {
+		// the module is stopped when ctx is, not just between calls
+		rc = rc.WithCloseOnContextDone(true)
+	}
+
+	r := wazero.NewRuntimeWithConfig(ctx, rc)
+	defer r.Close(ctx)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		return nil, fmt.Errorf("failed to provide WASI: %w", err)
+	}
+
+	compiled, err := r.CompileModule(ctx, bin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile module: %w", err)
+	}
+	// Reactors are initialised; a command's _start is not run, as it
+	// would execute main and exit before functionName could be called
+	config := cfg.moduleConfig().WithStartFunctions("_initialize")
+	mod, err := r.InstantiateModule(ctx, compiled, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+
+	fn := mod.ExportedFunction(functionName)
+	params, err := encodeLocalArgs(fn.Definition().ParamTypes(), args)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	results, callErr := fn.Call(ctx, params...)
+	// a WASI command calling proc_exit(0) has simply finished
+	var exit *sys.ExitError
+	if errors.As(callErr, &exit) && exit.ExitCode() == 0 {
+		callErr = nil
+	}
+	result := &ExecutionResult{
+		Success:       callErr == nil,
+		ExecutionTime: time.Since(start).Seconds() * 1000,
+		Local:         true,
+	}
+	// wazero hands back a typed nil for a module without memory, so ask
+	// the binary rather than compare the interface with nil
+	if parsed.hasMemory() {
+		result.MemoryUsed = int64(mod.Memory().Size())
+	}
+	if callErr != nil {
+		result.Error = callErr.Error()
+		used := uint64(result.MemoryUsed)
+		switch {
+		case errors.As(callErr, &exit) && exit.ExitCode() == sys.ExitCodeDeadlineExceeded:
+			result.Error = ErrExecutionTimeout.Error()
+			if cfg.Timeout > 0 {
+				result.Error += fmt.Sprintf(" after %v", cfg.Timeout)
+			}
+		case limitPages > 0 && used >= limitPages*wasmPageSize:
+			// wazero does not report refused memory.grow calls, but a
+			// module that failed with every allowed page in use ran out
+			result.Error = fmt.Sprintf("%v: %d of %d bytes in use: %s", ErrMemoryLimitExceeded, used, limitPages*wasmPageSize, callErr)
+		}
+		return result, nil
+	}
+	result.Result = decodeLocalResults(fn.Definition().ResultTypes(), results)
+	return result, nil
+}
+
+// encodeLocalArgs converts args, already validated against the signature,
+// to the raw values wazero passes to a function. Integers are passed
+// exactly; only float parameters go through float64.
+func encodeLocalArgs(types []api.ValueType, args []interface{}) ([]uint64, error) {
+	params := make([]uint64, len(types))
+	for i, t := range types {
+		switch t {
+		case api.ValueTypeI32, api.ValueTypeI64:
+			n, ok := argInt(args[i])
+			if !ok {
+				return nil, fmt.Errorf("cannot pass arg %d of type %s as an integer", i, argTypeName(args[i]))
+			}
+			if t == api.ValueTypeI32 {
+				params[i] = api.EncodeI32(int32(uint32(n)))
+			} else {
+				params[i] = api.EncodeI64(n)
+			}
+		case api.ValueTypeF32:
+			f, _, _ := argNumber(args[i])
+			params[i] = api.EncodeF32(float32(f))
+		case api.ValueTypeF64:
+			f, _, _ := argNumber(args[i])
+			params[i] = api.EncodeF64(f)
+		default:
+			return nil, fmt.Errorf("cannot pass arg %d of type %s to a local execution", i, api.ValueTypeName(t))
+		}
+	}
+	return params, nil
+}
+
+// decodeLocalResults converts raw results to Go values: nil for none, the
+// value itself for one and a slice for several
+func decodeLocalResults(types []api.ValueType, raw []uint64) interface{} {
+	if len(raw) < len(types) {
+		// the module exited before returning
+		return nil
+	}
+	values := make([]interface{}, len(types))
+	for i, t := range types {
+		switch t {
+		case api.ValueTypeI32:
+			values[i] = api.DecodeI32(raw[i])
+		case api.ValueTypeI64:
+			values[i] = int64(raw[i])
+		case api.ValueTypeF32:
+			values[i] = api.DecodeF32(raw[i])
+		case api.ValueTypeF64:
+			values[i] = math.Float64frombits(raw[i])
+		default:
+			values[i] = raw[i]
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return values[0]
+	}
+	return values
+}
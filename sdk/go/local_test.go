@@ -0,0 +1,63 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// identityI64 is (module (func (export "id") (param i64) (result i64)
+// local.get 0)), which has no linear memory
+var identityI64 = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x06, 0x01, 0x60, 0x01, 0x7e, 0x01, 0x7e, // type: (i64) -> i64
+	0x03, 0x02, 0x01, 0x00, // func 0 has type 0
+	0x07, 0x06, 0x01, 0x02, 'i', 'd', 0x00, 0x00, // export "id"
+	0x0a, 0x06, 0x01, 0x04, 0x00, 0x20, 0x00, 0x0b, // local.get 0
+}
+
+func writeModule(t *testing.T, bin []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mod.wasm")
+	if err := os.WriteFile(path, bin, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExecuteLocalWithoutMemory(t *testing.T) {
+	result, err := ExecuteLocal(writeModule(t, identityI64), "id", []interface{}{7})
+	if err != nil {
+		t.Fatalf("ExecuteLocal: %v", err)
+	}
+	if !result.Success || result.Result != int64(7) {
+		t.Errorf("got result %v (%s), want 7", result.Result, result.Error)
+	}
+	if result.MemoryUsed != 0 {
+		t.Errorf("got %d bytes of memory used, want 0", result.MemoryUsed)
+	}
+}
+
+func TestExecuteLocalKeepsI64Precision(t *testing.T) {
+	path := writeModule(t, identityI64)
+	for _, tt := range []struct {
+		arg  interface{}
+		want int64
+	}{
+		{int64(9007199254740993), 9007199254740993},
+		{int64(math.MaxInt64), math.MaxInt64},
+		{int64(math.MinInt64), math.MinInt64},
+		{json.Number("9007199254740993"), 9007199254740993},
+	} {
+		arg, want := tt.arg, tt.want
+		result, err := ExecuteLocal(path, "id", []interface{}{arg})
+		if err != nil {
+			t.Fatalf("ExecuteLocal(%v): %v", arg, err)
+		}
+		if result.Result != want {
+			t.Errorf("got %v back from %v, want %d", result.Result, arg, want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package wasmify
+
+import "time"
+
+// Logger receives a line for every HTTP request the client sends and the
+// response it gets back. Retries are logged as separate requests.
+type Logger interface {
+	// LogRequest is called just before a request is sent
+	LogRequest(method, url string)
+	// LogResponse is called once response headers arrive or the request
+	// fails; status is zero when no response was received
+	LogResponse(status int, duration time.Duration)
+}
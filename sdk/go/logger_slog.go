@@ -0,0 +1,30 @@
+//go:build go1.21
+
+package wasmify
+
+import (
+	"log/slog"
+	"time"
+)
+
+// slogLogger adapts a *slog.Logger to Logger
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger returns a Logger that writes to l at debug level, with the
+// method, URL, status and duration as attributes
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l}
+}
+
+func (s slogLogger) LogRequest(method, url string) {
+	s.l.Debug("wasmify request", "method", method, "url", url)
+}
+
+func (s slogLogger) LogResponse(status int, duration time.Duration) {
+	s.l.Debug("wasmify response", "status", status, "duration", duration)
+}
@@ -0,0 +1,145 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// MapFailurePolicy decides what ExecuteMapReduce does when map calls fail
+type MapFailurePolicy string
+
+const (
+	// MapFailFast aborts on the first failed map call
+	MapFailFast MapFailurePolicy = "fail-fast"
+	// MapSkipFailed reduces over the map calls that succeeded and drops
+	// the rest
+	MapSkipFailed MapFailurePolicy = "skip-failed"
+)
+
+// MapReduceOptions tunes ExecuteMapReduce
+type MapReduceOptions struct {
+	// Config applies to both the map and the reduce calls
+	Config ExecutionConfig
+	// Concurrency bounds the map calls in flight when they run on the
+	// client (default 8)
+	Concurrency int
+	// OnFailure defaults to MapFailFast
+	OnFailure MapFailurePolicy
+}
+
+// ExecuteMapReduce calls mapFn once per entry of inputs, concurrently, and
+// then reduceFn once with the list of map results as its only argument.
+// The server runs the whole pipeline when it supports it; otherwise the
+// client orchestrates it. A map call fails if its request fails or the
+// function reports an error, and options.OnFailure decides whether that
+// aborts the pipeline. The reduce call's result is returned.
+func (c *Client) ExecuteMapReduce(ctx context.Context, moduleID, mapFn, reduceFn string, inputs [][]interface{}, options MapReduceOptions, opts ...CallOption) (*ExecutionResult, error) {
+	if options.OnFailure == "" {
+		options.OnFailure = MapFailFast
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("map-reduce needs at least one input")
+	}
+
+	opts, _ = c.withCorrelationID(opts)
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.executeMapReduceRemote(ctx, moduleID, mapFn, reduceFn, inputs, options, opts)
+	if !isUnsupported(err) {
+		return result, err
+	}
+
+	mapped, err := c.executeMap(ctx, moduleID, mapFn, inputs, options, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.Execute(ctx, moduleID, reduceFn, []interface{}{mapped}, options.Config, opts...)
+}
+
+// executeMapReduceRemote runs the pipeline on the server's map-reduce
+// endpoint
+func (c *Client) executeMapReduceRemote(ctx context.Context, moduleID, mapFn, reduceFn string, inputs [][]interface{}, options MapReduceOptions, opts []CallOption) (*ExecutionResult, error) {
+	config := options.Config.withDefaults(c.config.DefaultExecutionConfig)
+	wireInputs := make([][]interface{}, len(inputs))
+	for i, args := range inputs {
+		if args == nil {
+			args = []interface{}{}
+		}
+		wireInputs[i] = args
+	}
+
+	req, err := c.newJSONRequest("map-reduce", http.MethodPost, "/wasm/execute/map-reduce", map[string]interface{}{
+		"moduleId":       moduleID,
+		"mapFunction":    mapFn,
+		"reduceFunction": reduceFn,
+		"inputs":         wireInputs,
+		"config":         config.wire(),
+		"onFailure":      options.OnFailure,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Result executionPayload `json:"result"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
+	}
+	return data.Result.toResult(config)
+}
+
+// executeMap runs the map calls on the client and returns their results
+// in input order, minus any dropped under MapSkipFailed
+func (c *Client) executeMap(ctx context.Context, moduleID, mapFn string, inputs [][]interface{}, options MapReduceOptions, opts []CallOption) ([]interface{}, error) {
+	limit := options.Concurrency
+	if limit <= 0 {
+		limit = defaultBatchConcurrency
+	}
+	failFast := options.OnFailure == MapFailFast
+
+	results := make([]*ExecutionResult, len(inputs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(limit)
+	for i := range inputs {
+		i := i
+		g.Go(func() error {
+			if failFast && gctx.Err() != nil {
+				return gctx.Err()
+			}
+			result, err := c.Execute(gctx, moduleID, mapFn, inputs[i], options.Config, opts...)
+			if err == nil && (!result.Success || result.Error != "") {
+				err = fmt.Errorf("function failed: %s", result.Error)
+			}
+			if err != nil {
+				if failFast {
+					return fmt.Errorf("map call %d: %w", i, err)
+				}
+				return nil
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	mapped := make([]interface{}, 0, len(results))
+	for _, r := range results {
+		if r != nil {
+			mapped = append(mapped, r.Result)
+		}
+	}
+	if len(mapped) == 0 {
+		return nil, fmt.Errorf("all %d map calls failed", len(inputs))
+	}
+	return mapped, nil
+}
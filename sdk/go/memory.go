@@ -0,0 +1,55 @@
+package wasmify
+
+import (
+	"errors"
+	"fmt"
+)
+
+// wasmPageSize is the size of a WebAssembly linear memory page
+const wasmPageSize = 64 << 10
+
+// codeMemoryLimitExceeded is the error code the runtime reports when a
+// module tries to use more memory than it was allowed
+const codeMemoryLimitExceeded = "memory_limit_exceeded"
+
+// ErrMemoryLimitExceeded is matched by the error returned when an
+// execution ran out of the memory its config allowed
+var ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+
+// memoryPages resolves the memory bounds of c in pages. MemoryLimitBytes
+// rounds down to whole pages so the limit is never exceeded, and wins
+// over a larger MemoryMax. Without an explicit MemoryMin the minimum is
+// lowered to fit under the limit.
+func (c ExecutionConfig) memoryPages() (min, max int, err error) {
+	min, max = defaultMemoryMin, defaultMemoryMax
+	if c.MemoryMin > 0 {
+		min = c.MemoryMin
+	}
+	if c.MemoryMax > 0 {
+		max = c.MemoryMax
+	}
+
+	if c.MemoryLimitBytes < 0 {
+		return 0, 0, fmt.Errorf("invalid MemoryLimitBytes %d: must not be negative", c.MemoryLimitBytes)
+	}
+	if c.MemoryLimitBytes > 0 {
+		pages := c.MemoryLimitBytes / wasmPageSize
+		if pages == 0 {
+			return 0, 0, fmt.Errorf("invalid MemoryLimitBytes %d: below one %d-byte page", c.MemoryLimitBytes, wasmPageSize)
+		}
+		if c.MemoryMax == 0 || int64(max) > pages {
+			max = int(pages)
+		}
+		if c.MemoryMin == 0 && min > max {
+			min = max
+		}
+	}
+
+	if min > max {
+		return 0, 0, fmt.Errorf("invalid memory config: minimum of %d pages exceeds maximum of %d", min, max)
+	}
+	if c.MemoryGrowLimit < 0 {
+		return 0, 0, fmt.Errorf("invalid MemoryGrowLimit %d: must not be negative", c.MemoryGrowLimit)
+	}
+	return min, max, nil
+}
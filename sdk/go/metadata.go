@@ -0,0 +1,221 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrMetadataInvalid is matched by the error returned when module metadata
+// does not satisfy Config.MetadataSchema
+var ErrMetadataInvalid = errors.New("metadata does not match schema")
+
+// MetadataSchema describes the metadata every module must carry. It reads
+// the object subset of JSON Schema: required, properties with type, enum,
+// pattern, minLength and maxLength, and additionalProperties.
+type MetadataSchema struct {
+	Required   []string                    `json:"required,omitempty"`
+	Properties map[string]MetadataProperty `json:"properties,omitempty"`
+	// AdditionalProperties false rejects keys not listed in Properties
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+}
+
+// MetadataProperty constrains a single metadata key
+type MetadataProperty struct {
+	// Type is "string", "number", "integer" or "boolean"; empty accepts
+	// any value
+	Type      string        `json:"type,omitempty"`
+	Enum      []interface{} `json:"enum,omitempty"`
+	Pattern   string        `json:"pattern,omitempty"`
+	MinLength int           `json:"minLength,omitempty"`
+	MaxLength int           `json:"maxLength,omitempty"`
+}
+
+// ParseMetadataSchema reads a JSON Schema document, keeping the keywords
+// MetadataSchema understands and rejecting an invalid pattern
+func ParseMetadataSchema(data []byte) (*MetadataSchema, error) {
+	var s MetadataSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid metadata schema: %w", err)
+	}
+	if _, err := s.patterns(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// patterns compiles the schema's patterns. It runs on every validation so
+// a schema stays a plain value, safe to share between clients.
+func (s *MetadataSchema) patterns() (map[string]*regexp.Regexp, error) {
+	res := make(map[string]*regexp.Regexp)
+	for key, p := range s.Properties {
+		if p.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata schema: pattern of %q: %w", key, err)
+		}
+		res[key] = re
+	}
+	return res, nil
+}
+
+// MetadataFieldError is a single metadata key that broke the schema
+type MetadataFieldError struct {
+	Field   string
+	Problem string
+}
+
+// MetadataError lists every metadata key that broke the schema. It
+// matches ErrMetadataInvalid.
+type MetadataError struct {
+	Fields []MetadataFieldError
+}
+
+func (e *MetadataError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = fmt.Sprintf("%q %s", f.Field, f.Problem)
+	}
+	return fmt.Sprintf("%v: %s", ErrMetadataInvalid, strings.Join(msgs, "; "))
+}
+
+func (e *MetadataError) Is(target error) bool {
+	return target == ErrMetadataInvalid
+}
+
+// ValidateMetadata checks a module's complete metadata against s
+func (s *MetadataSchema) ValidateMetadata(metadata map[string]interface{}) error {
+	return s.validate(metadata, false)
+}
+
+// validateUpload checks the metadata of an upload against s
+func (s *MetadataSchema) validateUpload(metadata map[string]string) error {
+	m := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		m[k] = v
+	}
+	return s.validate(m, false)
+}
+
+// validatePatch checks a merge patch against s. Keys the patch leaves out
+// keep their stored values and are not checked, but clearing a required
+// key is an error.
+func (s *MetadataSchema) validatePatch(patch map[string]interface{}) error {
+	return s.validate(patch, true)
+}
+
+func (s *MetadataSchema) validate(metadata map[string]interface{}, patch bool) error {
+	patterns, err := s.patterns()
+	if err != nil {
+		return err
+	}
+
+	var fields []MetadataFieldError
+	for _, key := range s.Required {
+		v, ok := metadata[key]
+		switch {
+		case patch && ok && v == nil:
+			fields = append(fields, MetadataFieldError{key, "is required and cannot be cleared"})
+		case !patch && (!ok || v == nil || v == ""):
+			fields = append(fields, MetadataFieldError{key, "is required"})
+		}
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		v := metadata[key]
+		if v == nil {
+			continue
+		}
+		p, ok := s.Properties[key]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				fields = append(fields, MetadataFieldError{key, "is not allowed"})
+			}
+			continue
+		}
+		if problem := p.check(v, patterns[key]); problem != "" {
+			fields = append(fields, MetadataFieldError{key, problem})
+		}
+	}
+
+	if len(fields) > 0 {
+		return &MetadataError{Fields: fields}
+	}
+	return nil
+}
+
+// check returns what is wrong with v, or "". Upload metadata is all
+// strings, so a string holding a number or boolean satisfies those types.
+func (p MetadataProperty) check(v interface{}, pattern *regexp.Regexp) string {
+	switch p.Type {
+	case "", "string":
+		if _, ok := v.(string); !ok && p.Type != "" {
+			return "must be a string"
+		}
+	case "number", "integer":
+		f, ok := metadataNumber(v)
+		if !ok {
+			return "must be a number"
+		}
+		if p.Type == "integer" && f != math.Trunc(f) {
+			return "must be an integer"
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			if s, isString := v.(string); !isString || (s != "true" && s != "false") {
+				return "must be a boolean"
+			}
+		}
+	}
+
+	if len(p.Enum) > 0 && !metadataInEnum(v, p.Enum) {
+		return fmt.Sprintf("must be one of %v", p.Enum)
+	}
+
+	if s, ok := v.(string); ok {
+		n := len([]rune(s))
+		if p.MinLength > 0 && n < p.MinLength {
+			return fmt.Sprintf("must be at least %d characters", p.MinLength)
+		}
+		if p.MaxLength > 0 && n > p.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", p.MaxLength)
+		}
+		if pattern != nil && !pattern.MatchString(s) {
+			return fmt.Sprintf("must match %q", p.Pattern)
+		}
+	}
+	return ""
+}
+
+func metadataNumber(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	f, _, ok := argNumber(v)
+	return f, ok
+}
+
+// metadataInEnum compares by string form, so upload metadata strings
+// match numeric and boolean enum values
+func metadataInEnum(v interface{}, enum []interface{}) bool {
+	want := fmt.Sprint(v)
+	for _, e := range enum {
+		if fmt.Sprint(e) == want {
+			return true
+		}
+	}
+	return false
+}
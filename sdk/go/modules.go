@@ -0,0 +1,252 @@
+package wasmify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// GetModules fetches several modules in one request and returns them keyed
+// by ID. IDs that do not exist are left out of the map. Servers without
+// the batch endpoint are queried with concurrent GetModule calls instead.
+func (c *Client) GetModules(ctx context.Context, ids []string, opts ...CallOption) (map[string]*WasmModule, error) {
+	ids = uniqueStrings(ids)
+	if len(ids) == 0 {
+		return map[string]*WasmModule{}, nil
+	}
+
+	req, err := c.newJSONRequest("get modules", http.MethodPost, "/modules/batch-get", map[string]interface{}{
+		"ids": ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Modules []moduleRecord `json:"modules"`
+	}
+	err = c.doJSON(ctx, req, opts, &data)
+	if isUnsupported(err) {
+		return c.getModulesConcurrently(ctx, ids, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make(map[string]*WasmModule, len(data.Modules))
+	for i := range data.Modules {
+		modules[data.Modules[i].ID] = data.Modules[i].toModule()
+	}
+	return modules, nil
+}
+
+// StreamModules lists modules, sending each one on the returned channel as
+// soon as it has been decoded so huge catalogs never sit in memory at
+// once. The module channel is closed when the listing ends; the error
+// channel then yields the listing's error, or nil, and is closed too.
+// Cancelling ctx stops the listing.
+func (c *Client) StreamModules(ctx context.Context, opts ...CallOption) (<-chan *WasmModule, <-chan error) {
+	modules := make(chan *WasmModule)
+	errc := make(chan error, 1)
+
+	req := &request{
+		op:     "list",
+		method: http.MethodGet,
+		path:   "/modules",
+	}
+
+	go func() {
+		defer close(errc)
+		err := c.doJSONList(ctx, req, opts, func(decode func(interface{}) error) error {
+			var rec moduleRecord
+			if err := decode(&rec); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+			select {
+			case modules <- rec.toModule():
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(modules)
+		errc <- err
+	}()
+
+	return modules, errc
+}
+
+// SetModuleRateLimit caps how many times per second a module may be
+// invoked; zero removes the cap. Executions over the limit fail with an
+// error matching ErrModuleRateLimited.
+func (c *Client) SetModuleRateLimit(ctx context.Context, moduleID string, rps int, opts ...CallOption) error {
+	if rps < 0 {
+		return fmt.Errorf("invalid rate limit %d: must not be negative", rps)
+	}
+
+	req, err := c.newJSONRequest("set rate limit", http.MethodPut, "/modules/"+url.PathEscape(moduleID)+"/rate-limit", map[string]interface{}{
+		"rps": rps,
+	})
+	if err != nil {
+		return err
+	}
+	return c.doJSON(ctx, req, opts, nil)
+}
+
+// DeleteModule permanently removes a module. Deleting a module that does
+// not exist fails with a *ModuleNotFoundError.
+func (c *Client) DeleteModule(moduleID string, opts ...CallOption) error {
+	return c.DeleteModuleContext(context.Background(), moduleID, opts...)
+}
+
+// DeleteModuleContext is like DeleteModule but stops when ctx is done
+func (c *Client) DeleteModuleContext(ctx context.Context, moduleID string, opts ...CallOption) error {
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return err
+	}
+
+	req := &request{
+		op:     "delete module",
+		method: http.MethodDelete,
+		path:   "/modules/" + url.PathEscape(moduleID),
+	}
+	return moduleNotFound(moduleID, c.doJSON(ctx, req, opts, nil))
+}
+
+// mergePatchContentType is the media type of RFC 7396 JSON Merge Patch
+const mergePatchContentType = "application/merge-patch+json"
+
+// UpdateModuleMetadata applies patch to a module's metadata as an RFC 7396
+// JSON Merge Patch and returns the updated module, whose Metadata holds
+// the merged result. Keys set to nil are cleared, keys left out of patch
+// are untouched, and nested maps are merged the same way. The patch is
+// sent under "metadata", so its keys never touch fields such as the name;
+// use UpdateModule for those. The body is always JSON, whatever the
+// client codec.
+// With a MetadataSchema the keys in patch are checked before sending, and
+// clearing a required key is rejected.
+func (c *Client) UpdateModuleMetadata(ctx context.Context, moduleID string, patch map[string]interface{}, opts ...CallOption) (*WasmModule, error) {
+	if c.config.MetadataSchema != nil {
+		if err := c.config.MetadataSchema.validatePatch(patch); err != nil {
+			return nil, err
+		}
+	}
+	if patch == nil {
+		patch = map[string]interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{"metadata": patch})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req := &request{
+		op:          "update metadata",
+		method:      http.MethodPatch,
+		path:        "/modules/" + url.PathEscape(moduleID),
+		body:        body,
+		contentType: mergePatchContentType,
+	}
+
+	var data moduleRecord
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
+	}
+	return data.toModule(), nil
+}
+
+// getModulesConcurrently is the GetModules fallback for servers without
+// the batch endpoint
+func (c *Client) getModulesConcurrently(ctx context.Context, ids []string, opts []CallOption) (map[string]*WasmModule, error) {
+	var mu sync.Mutex
+	modules := make(map[string]*WasmModule, len(ids))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(defaultBatchConcurrency)
+	for _, id := range ids {
+		id := id
+		g.Go(func() error {
+			module, err := c.GetModuleContext(gctx, id, opts...)
+			if IsNotFound(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			modules[id] = module
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// uniqueStrings returns ss without duplicates, keeping the first occurrence
+func uniqueStrings(ss []string) []string {
+	seen := make(map[string]bool, len(ss))
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// ModulePatch lists the module fields UpdateModule changes; empty strings
+// and a nil IsPublic leave the field as it is
+type ModulePatch struct {
+	Name        string
+	Version     string
+	Description string
+	IsPublic    *bool
+}
+
+// UpdateModule renames a module, changes its version label or description,
+// or toggles its visibility without re-uploading it, and returns the
+// updated module. Only the fields set in patch are sent.
+func (c *Client) UpdateModule(moduleID string, patch ModulePatch, opts ...CallOption) (*WasmModule, error) {
+	return c.UpdateModuleContext(context.Background(), moduleID, patch, opts...)
+}
+
+// UpdateModuleContext is like UpdateModule but stops when ctx is done
+func (c *Client) UpdateModuleContext(ctx context.Context, moduleID string, patch ModulePatch, opts ...CallOption) (*WasmModule, error) {
+	fields := map[string]interface{}{}
+	if patch.Name != "" {
+		fields["name"] = patch.Name
+	}
+	if patch.Version != "" {
+		fields["version"] = patch.Version
+	}
+	if patch.Description != "" {
+		fields["description"] = patch.Description
+	}
+	if patch.IsPublic != nil {
+		fields["isPublic"] = *patch.IsPublic
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("update of %s has no fields set", moduleID)
+	}
+
+	req, err := c.newJSONRequest("update module", http.MethodPatch, "/modules/"+url.PathEscape(moduleID), fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var data moduleRecord
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
+	}
+	return data.toModule(), nil
+}
@@ -0,0 +1,80 @@
+package wasmify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateModuleMetadata(t *testing.T) {
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/modules/m1" {
+			t.Errorf("got %s %s, want PATCH /modules/m1", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != mergePatchContentType {
+			t.Errorf("Content-Type = %q, want %q", ct, mergePatchContentType)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var got map[string]map[string]interface{}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("body %s is not a JSON object: %v", body, err)
+		}
+		patch, ok := got["metadata"]
+		if !ok || len(got) != 1 {
+			t.Fatalf("body %s does not nest the patch under metadata", body)
+		}
+		if patch["owner"] != "team-a" {
+			t.Errorf("set key owner = %v, want team-a", patch["owner"])
+		}
+		if v, ok := patch["stale"]; !ok || v != nil {
+			t.Errorf("cleared key stale = %v (present %v), want null", v, ok)
+		}
+		if _, ok := patch["kept"]; ok {
+			t.Errorf("untouched key kept was sent")
+		}
+
+		writeData(t, w, map[string]interface{}{
+			"id":       "m1",
+			"name":     "mod",
+			"metadata": map[string]interface{}{"owner": "team-a", "kept": "yes"},
+		})
+	})
+
+	module, err := c.UpdateModuleMetadata(context.Background(), "m1", map[string]interface{}{
+		"owner": "team-a",
+		"stale": nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if module.Name != "mod" {
+		t.Errorf("Name = %q, want mod", module.Name)
+	}
+	if module.Metadata["owner"] != "team-a" || module.Metadata["kept"] != "yes" {
+		t.Errorf("Metadata = %v, want the merged owner and kept keys", module.Metadata)
+	}
+	if _, ok := module.Metadata["stale"]; ok {
+		t.Errorf("Metadata still has the cleared key: %v", module.Metadata)
+	}
+}
+
+func TestUpdateModuleMetadataKeepsNameKeysInMetadata(t *testing.T) {
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var got map[string]interface{}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := got["name"]; ok {
+			t.Errorf("metadata key name leaked to the top level: %s", body)
+		}
+		writeData(t, w, map[string]interface{}{"id": "m1"})
+	})
+
+	if _, err := c.UpdateModuleMetadata(context.Background(), "m1", map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+}
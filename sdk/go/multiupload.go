@@ -0,0 +1,105 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultUploadConcurrency is used by UploadModules when given no limit
+const defaultUploadConcurrency = 4
+
+// UploadSpec is a single module within UploadModules
+type UploadSpec struct {
+	FilePath string
+	Name     string
+	Version  string
+	Options  UploadOptions
+}
+
+// UploadsError collects the failures of UploadModules. Errors is indexed
+// like the specs, holding nil for modules that uploaded.
+type UploadsError struct {
+	Errors []error
+}
+
+func (e *UploadsError) Error() string {
+	var msgs []string
+	for i, err := range e.Errors {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("upload %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d uploads failed: %s", len(msgs), len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Is reports whether any of the failures matches target, so errors.Is
+// looks through an *UploadsError
+func (e *UploadsError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if err != nil && errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first failure that matches target, so errors.As looks
+// through an *UploadsError
+func (e *UploadsError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if err != nil && errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadModules uploads several modules in parallel, at most concurrency
+// at a time (4 if zero or less), and returns them in the order of specs.
+// The first failure cancels the uploads still running and those not yet
+// started, which fail with the context's error. Failures are returned
+// together as an *UploadsError with nil entries in the result; modules
+// uploaded before the failure are kept.
+func (c *Client) UploadModules(specs []UploadSpec, concurrency int, opts ...CallOption) ([]*WasmModule, error) {
+	return c.UploadModulesContext(context.Background(), specs, concurrency, opts...)
+}
+
+// UploadModulesContext is like UploadModules but stops when ctx is done,
+// aborting the uploads left as a failure would
+func (c *Client) UploadModulesContext(ctx context.Context, specs []UploadSpec, concurrency int, opts ...CallOption) ([]*WasmModule, error) {
+	if concurrency <= 0 {
+		concurrency = defaultUploadConcurrency
+	}
+
+	modules := make([]*WasmModule, len(specs))
+	errs := make([]error, len(specs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i := range specs {
+		i := i
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				errs[i] = err
+				return err
+			}
+			s := specs[i]
+			module, err := c.UploadModuleWithOptions(gctx, s.FilePath, s.Name, s.Version, s.Options, opts...)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", s.FilePath, err)
+				return errs[i]
+			}
+			modules[i] = module
+			return nil
+		})
+	}
+
+	if g.Wait() != nil {
+		return modules, &UploadsError{Errors: errs}
+	}
+	return modules, nil
+}
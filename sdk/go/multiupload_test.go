@@ -0,0 +1,47 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUploadModulesAbortsAfterFailure(t *testing.T) {
+	var uploads int32
+	c := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&uploads, 1)
+		writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod"})
+	})
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.wasm")
+	if err := os.WriteFile(good, []byte("\x00asm\x01\x00\x00\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specs := []UploadSpec{
+		{FilePath: filepath.Join(dir, "missing.wasm"), Name: "missing", Version: "1.0.0"},
+		{FilePath: good, Name: "good", Version: "1.0.0"},
+	}
+
+	modules, err := c.UploadModules(specs, 1)
+	var uerr *UploadsError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("got error %v, want an *UploadsError", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got error %v, want it to match os.ErrNotExist", err)
+	}
+	if !errors.Is(uerr.Errors[1], context.Canceled) {
+		t.Errorf("got %v for the second upload, want it cancelled", uerr.Errors[1])
+	}
+	if modules[0] != nil || modules[1] != nil {
+		t.Errorf("got modules %v, want none", modules)
+	}
+	if n := atomic.LoadInt32(&uploads); n != 0 {
+		t.Errorf("server got %d uploads, want 0", n)
+	}
+}
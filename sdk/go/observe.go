@@ -0,0 +1,68 @@
+package wasmify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RequestEvent describes a single HTTP round trip made by the client. It is
+// delivered to Config.OnRequest, typically to record metrics.
+type RequestEvent struct {
+	// Op names the operation, e.g. "upload"
+	Op     string
+	Method string
+	Path   string
+	// Attempt counts from zero; values above zero are retries
+	Attempt int
+	// StatusCode is zero when no response was received
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+	// Labels holds the context values found for Config.ContextLabels
+	Labels map[string]string
+	// CorrelationID is the ID sent in the X-Correlation-ID header
+	CorrelationID string
+}
+
+// contextLabels extracts the configured labels from ctx. Only the keys in
+// Config.ContextLabels are read; labels whose key is absent are omitted.
+func (c *Client) contextLabels(ctx context.Context) map[string]string {
+	if len(c.config.ContextLabels) == 0 || ctx == nil {
+		return nil
+	}
+
+	var labels map[string]string
+	for name, key := range c.config.ContextLabels {
+		v := ctx.Value(key)
+		if v == nil {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string, len(c.config.ContextLabels))
+		}
+		labels[name] = fmt.Sprint(v)
+	}
+	return labels
+}
+
+// formatLabels renders labels as sorted name=value pairs
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, v := range labels {
+		pairs = append(pairs, name+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// observe reports a finished round trip to Config.OnRequest
+func (c *Client) observe(ctx context.Context, ev RequestEvent) {
+	if c.config.OnRequest == nil {
+		return
+	}
+	ev.Labels = c.contextLabels(ctx)
+	c.config.OnRequest(ev)
+}
@@ -0,0 +1,86 @@
+package wasmify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// readyPollInterval is how often UploadAndExecute retries an execution
+// while a freshly uploaded module is not yet available
+const readyPollInterval = 500 * time.Millisecond
+
+// Stages of UploadAndExecute reported by StageError
+const (
+	StageUpload  = "upload"
+	StageExecute = "execute"
+	StageDelete  = "delete"
+)
+
+// StageError reports which step of UploadAndExecute failed
+type StageError struct {
+	// Stage is StageUpload, StageExecute or StageDelete
+	Stage string
+	// ModuleID is empty when the upload failed
+	ModuleID string
+	Err      error
+}
+
+func (e *StageError) Error() string {
+	if e.ModuleID == "" {
+		return fmt.Sprintf("%s failed: %v", e.Stage, e.Err)
+	}
+	return fmt.Sprintf("%s of module %s failed: %v", e.Stage, e.ModuleID, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// UploadAndExecuteOptions configures UploadAndExecute
+type UploadAndExecuteOptions struct {
+	Upload UploadOptions
+	// DeleteAfter removes the module once the execution has finished,
+	// whether or not it succeeded
+	DeleteAfter bool
+}
+
+// UploadAndExecute uploads a module and executes fn on it in one call,
+// for trying out a module straight after building it. While the server
+// has not yet made the upload available, the execution is retried until
+// ctx is done. Failures are returned as a *StageError naming the step. If
+// only the deletion fails, the result is returned along with the error.
+func (c *Client) UploadAndExecute(ctx context.Context, filePath, name, version, fn string, args []interface{}, config ExecutionConfig, options UploadAndExecuteOptions, opts ...CallOption) (*ExecutionResult, error) {
+	module, err := c.UploadModuleWithOptions(ctx, filePath, name, version, options.Upload, opts...)
+	if err != nil {
+		return nil, &StageError{Stage: StageUpload, Err: err}
+	}
+
+	result, err := c.executeWhenReady(ctx, module.ID, fn, args, config, opts)
+	if err != nil {
+		err = &StageError{Stage: StageExecute, ModuleID: module.ID, Err: err}
+	}
+
+	if options.DeleteAfter {
+		// clean up even when ctx is what ended the execution
+		dctx, cancel := c.cleanupContext()
+		defer cancel()
+		if derr := c.DeleteModuleContext(dctx, module.ID, opts...); derr != nil && err == nil {
+			err = &StageError{Stage: StageDelete, ModuleID: module.ID, Err: derr}
+		}
+	}
+	return result, err
+}
+
+// executeWhenReady executes fn, retrying while the module is not found
+func (c *Client) executeWhenReady(ctx context.Context, moduleID, fn string, args []interface{}, config ExecutionConfig, opts []CallOption) (*ExecutionResult, error) {
+	for {
+		result, err := c.Execute(ctx, moduleID, fn, args, config, opts...)
+		if !IsNotFound(err) {
+			return result, err
+		}
+		if serr := sleepContext(ctx, readyPollInterval); serr != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUploadAndExecuteDeletesWithTimeoutDisabled(t *testing.T) {
+	var deleted int32
+	c := newTestClient(t, Config{Timeout: -1}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/upload":
+			writeData(t, w, map[string]interface{}{"key": "mod-1"})
+		case r.URL.Path == "/wasm/execute":
+			writeData(t, w, map[string]interface{}{"result": map[string]interface{}{"result": 1}})
+		case r.URL.Path == "/modules/mod-1" && r.Method == http.MethodDelete:
+			atomic.AddInt32(&deleted, 1)
+			writeData(t, w, nil)
+		case r.URL.Path == "/modules/mod-1":
+			writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod"})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "mod.wasm")
+	if err := os.WriteFile(path, []byte("\x00asm\x01\x00\x00\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	options := UploadAndExecuteOptions{DeleteAfter: true}
+	if _, err := c.UploadAndExecute(context.Background(), path, "mod", "1.0.0", "run", nil, ExecutionConfig{}, options); err != nil {
+		t.Fatalf("UploadAndExecute: %v", err)
+	}
+	if n := atomic.LoadInt32(&deleted); n != 1 {
+		t.Errorf("server got %d deletes, want 1", n)
+	}
+}
@@ -0,0 +1,33 @@
+package wasmify
+
+// CallOption customizes a single API call without changing the client
+// defaults
+type CallOption func(*callOptions)
+
+// callOptions holds the per-call settings collected from CallOptions
+type callOptions struct {
+	retry *RetryPolicy
+	// correlationID is sent in the X-Correlation-ID header
+	correlationID string
+}
+
+// WithRetryPolicy overrides the client retry policy for a single call
+func WithRetryPolicy(policy RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = &policy
+	}
+}
+
+// newCallOptions applies opts on top of the client defaults
+func (c *Client) newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	if o.retry == nil {
+		o.retry = &c.retry
+	}
+	return o
+}
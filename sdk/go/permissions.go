@@ -0,0 +1,85 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Actions understood by CheckPermission
+const (
+	ActionUpload  = "upload"
+	ActionExecute = "execute"
+	ActionDeploy  = "deploy"
+	ActionDelete  = "delete"
+)
+
+// permissionCacheTTL is how long a CheckPermission answer is reused
+const permissionCacheTTL = 30 * time.Second
+
+// permissionCache remembers recent CheckPermission answers
+type permissionCache struct {
+	mu      sync.Mutex
+	entries map[string]permissionEntry
+}
+
+type permissionEntry struct {
+	allowed bool
+	expires time.Time
+}
+
+func (pc *permissionCache) get(key string, now time.Time) (bool, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	e, ok := pc.entries[key]
+	if !ok || !now.Before(e.expires) {
+		return false, false
+	}
+	return e.allowed, true
+}
+
+func (pc *permissionCache) put(key string, allowed bool, now time.Time) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.entries == nil {
+		pc.entries = make(map[string]permissionEntry)
+	}
+	for k, e := range pc.entries {
+		if !now.Before(e.expires) {
+			delete(pc.entries, k)
+		}
+	}
+	pc.entries[key] = permissionEntry{allowed: allowed, expires: now.Add(permissionCacheTTL)}
+}
+
+// CheckPermission asks the server whether the client's credentials may
+// perform action, such as ActionDeploy, on resource, typically a module or
+// deployment ID. Answers are cached for 30 seconds, so UIs can call it
+// freely to decide which controls to enable.
+func (c *Client) CheckPermission(ctx context.Context, action, resource string, opts ...CallOption) (bool, error) {
+	key := action + "\x00" + resource
+	if allowed, ok := c.permissions.get(key, time.Now()); ok {
+		return allowed, nil
+	}
+
+	req, err := c.newJSONRequest("permission check", http.MethodPost, "/permissions/check", map[string]interface{}{
+		"action":   action,
+		"resource": resource,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	var data struct {
+		Allowed bool `json:"allowed"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return false, err
+	}
+
+	c.permissions.put(key, data.Allowed, time.Now())
+	return data.Allowed, nil
+}
@@ -0,0 +1,65 @@
+package wasmify
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// pinPrefix marks a content-addressed module reference
+const pinPrefix = "sha256:"
+
+// ErrDigestMismatch is returned when content resolved from a pinned
+// reference does not hash to the pinned digest
+var ErrDigestMismatch = errors.New("content does not match pinned digest")
+
+// PinModule returns the immutable reference for content with the given
+// hex-encoded SHA-256 digest. The reference is accepted anywhere a module ID
+// is, and always resolves to exactly that content.
+func PinModule(digest string) string {
+	return pinPrefix + normalizeDigest(digest)
+}
+
+// parsePin extracts the digest from a "sha256:<hex>" reference. ok is false
+// for ordinary module IDs.
+func parsePin(ref string) (digest string, ok bool, err error) {
+	if !strings.HasPrefix(ref, pinPrefix) {
+		return "", false, nil
+	}
+	digest = strings.ToLower(strings.TrimPrefix(ref, pinPrefix))
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != 32 {
+		return "", true, fmt.Errorf("invalid pinned reference %q: expected 64 hex characters", ref)
+	}
+	return digest, true, nil
+}
+
+// normalizeDigest strips an optional algorithm prefix and case from a
+// hex-encoded SHA-256 digest
+func normalizeDigest(digest string) string {
+	return strings.ToLower(strings.TrimPrefix(digest, pinPrefix))
+}
+
+// resolveModuleRef turns a pinned reference into the ID of the module with
+// exactly that content, and a "name@version" reference into the ID of that
+// version. Ordinary IDs are returned unchanged.
+func (c *Client) resolveModuleRef(ctx context.Context, ref string, opts []CallOption) (string, error) {
+	if _, _, ok := parseVersionRef(ref); ok {
+		return c.resolveVersionRef(ctx, ref, opts)
+	}
+
+	digest, ok, err := parsePin(ref)
+	if err != nil || !ok {
+		return ref, err
+	}
+
+	module, err := c.GetModuleContext(ctx, ref, opts...)
+	if err != nil {
+		return "", err
+	}
+	if normalizeDigest(module.Hash) != digest {
+		return "", fmt.Errorf("%w: %s resolved to module %s with digest %q", ErrDigestMismatch, ref, module.ID, module.Hash)
+	}
+	return module.ID, nil
+}
@@ -0,0 +1,100 @@
+package wasmify
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Policy is a set of rules a module must satisfy before it is uploaded. The
+// zero value allows everything. Set Config.Policy to have UploadModule
+// enforce it client-side.
+type Policy struct {
+	// MaxSize is the largest module accepted, in bytes; zero means no limit
+	MaxSize int64
+	// DeniedImports lists imports the module may not use. An entry matches
+	// either an import module ("wasi_sockets") or a single import written
+	// as "module.name"; both forms accept path.Match wildcards, e.g.
+	// "wasi_snapshot_preview1.sock_*".
+	DeniedImports []string
+	// RequiredMetadata lists UploadOptions.Metadata keys that must be set
+	// to a non-empty value
+	RequiredMetadata []string
+}
+
+// PolicyViolation is a single broken policy rule
+type PolicyViolation struct {
+	// Rule is "max-size", "denied-import" or "required-metadata"
+	Rule    string
+	Message string
+}
+
+// PolicyError aggregates every violation found while validating a module
+type PolicyError struct {
+	Path       string
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Message
+	}
+	return fmt.Sprintf("module %s violates policy: %s", e.Path, strings.Join(msgs, "; "))
+}
+
+// Validate checks the module at wasmFilePath, uploaded with opts, against
+// the policy. All violations are collected and returned together as a
+// *PolicyError.
+func (p Policy) Validate(wasmFilePath string, opts UploadOptions) error {
+	bin, err := parseWasmFile(wasmFilePath)
+	if err != nil {
+		return err
+	}
+
+	var violations []PolicyViolation
+
+	if p.MaxSize > 0 && bin.size > p.MaxSize {
+		violations = append(violations, PolicyViolation{
+			Rule:    "max-size",
+			Message: fmt.Sprintf("size %d bytes exceeds limit of %d bytes", bin.size, p.MaxSize),
+		})
+	}
+
+	for _, imp := range bin.imports {
+		if pattern, ok := p.deniedImport(imp); ok {
+			violations = append(violations, PolicyViolation{
+				Rule:    "denied-import",
+				Message: fmt.Sprintf("import %s.%s is denied by %q", imp.module, imp.name, pattern),
+			})
+		}
+	}
+
+	for _, key := range p.RequiredMetadata {
+		if opts.Metadata[key] == "" {
+			violations = append(violations, PolicyViolation{
+				Rule:    "required-metadata",
+				Message: fmt.Sprintf("metadata %q is required", key),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Path: wasmFilePath, Violations: violations}
+	}
+	return nil
+}
+
+// deniedImport returns the DeniedImports entry matching imp, if any
+func (p Policy) deniedImport(imp wasmImport) (string, bool) {
+	qualified := imp.module + "." + imp.name
+	for _, pattern := range p.DeniedImports {
+		if ok, _ := path.Match(pattern, imp.module); ok {
+			return pattern, true
+		}
+		if ok, _ := path.Match(pattern, qualified); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,75 @@
+package wasmify
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrAuthenticationRequired is matched by errors for requests a gateway
+// redirected to a login or other authentication page, which usually means
+// the API key is missing or a session is needed
+var ErrAuthenticationRequired = errors.New("authentication required")
+
+// maxRedirects matches the net/http default
+const maxRedirects = 10
+
+// authPathHints are path and host fragments that mark an authentication
+// endpoint
+var authPathHints = []string{"login", "signin", "sign-in", "oauth", "authorize", "auth", "sso", "saml"}
+
+// authRedirectError reports where an authentication redirect pointed
+type authRedirectError struct {
+	location *url.URL
+}
+
+func (e *authRedirectError) Error() string {
+	// the query is dropped as it often carries state or tokens
+	loc := url.URL{Scheme: e.location.Scheme, Host: e.location.Host, Path: e.location.Path}
+	return fmt.Sprintf("%v: redirected to %s", ErrAuthenticationRequired, loc.String())
+}
+
+func (e *authRedirectError) Unwrap() error {
+	return ErrAuthenticationRequired
+}
+
+// checkRedirect follows redirects to other resources, such as a storage
+// host serving a download, but stops at authentication endpoints
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if isAuthURL(req.URL) {
+		return &authRedirectError{location: req.URL}
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+func isAuthURL(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	path := strings.ToLower(u.Path)
+	for _, hint := range authPathHints {
+		if strings.HasPrefix(host, hint+".") {
+			return true
+		}
+		for _, seg := range strings.Split(path, "/") {
+			if seg == hint || strings.HasPrefix(seg, hint+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redirectedToHTML reports whether resp is an HTML page reached by
+// following a redirect, which an API endpoint never serves
+func redirectedToHTML(req *http.Request, resp *http.Response) bool {
+	if resp.Request == nil || resp.Request.URL.String() == req.URL.String() {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "text/html"
+}
@@ -0,0 +1,47 @@
+package wasmify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// replayableSource returns a function that yields src afresh for every
+// attempt of a request, and whether it can do so more than once. Seekable
+// sources are rewound; others are buffered in memory when they fit within
+// limit bytes. A larger source is sent once, starting with the bytes
+// already buffered.
+func replayableSource(src io.Reader, limit int64) (open func() (io.Reader, error), replayable bool, err error) {
+	if s, ok := src.(io.Seeker); ok {
+		if start, err := s.Seek(0, io.SeekCurrent); err == nil {
+			return func() (io.Reader, error) {
+				if _, err := s.Seek(start, io.SeekStart); err != nil {
+					return nil, fmt.Errorf("failed to rewind request body: %w", err)
+				}
+				return src, nil
+			}, true, nil
+		}
+	}
+
+	var buf []byte
+	if limit > 0 {
+		if buf, err = io.ReadAll(io.LimitReader(src, limit+1)); err != nil {
+			return nil, false, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if int64(len(buf)) <= limit {
+			return func() (io.Reader, error) {
+				return bytes.NewReader(buf), nil
+			}, true, nil
+		}
+	}
+
+	used := false
+	return func() (io.Reader, error) {
+		if used {
+			return nil, errors.New("request body cannot be sent twice")
+		}
+		used = true
+		return io.MultiReader(bytes.NewReader(buf), src), nil
+	}, false, nil
+}
@@ -0,0 +1,580 @@
+package wasmify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// request describes a single call against the Wasmify API. The body is kept
+// as a byte slice so every attempt can replay it, or produced afresh for
+// every attempt by stream when it is too large to hold in memory.
+type request struct {
+	// op names the operation in error messages, e.g. "upload"
+	op     string
+	method string
+	path   string
+	body   []byte
+	// stream also returns the body length, or -1 when it is unknown
+	stream      func() (io.ReadCloser, int64, error)
+	contentType string
+	header      http.Header
+	// onResponse, when set, sees the successful response before it is
+	// handled
+	onResponse func(*http.Response)
+	// oneShot marks a body that cannot be replayed, so the request is
+	// never retried
+	oneShot bool
+	// streaming marks a long-lived response, which Config.Timeout does
+	// not cut short; only the context bounds it
+	streaming bool
+	// download marks a response body paced by MaxDownloadBytesPerSec
+	download bool
+}
+
+// bodyError is returned by a streamed request body that gave up on its own
+// accord. It is not transient, so the attempt fails with the wrapped error
+// instead of being retried.
+type bodyError struct {
+	err error
+}
+
+func (e *bodyError) Error() string { return e.err.Error() }
+
+func (e *bodyError) Unwrap() error { return e.err }
+
+// truncatedError reports a response body that ended early, typically
+// because the connection dropped. The request is retried.
+func truncatedError(read int64, err error) error {
+	return &retryableError{fmt.Errorf("response truncated after %d bytes, likely a dropped connection: %w", read, err)}
+}
+
+// isTruncation reports whether a read or decode error means the body
+// stopped before the document was complete
+func isTruncation(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr) && syntaxErr.Error() == "unexpected end of JSON input"
+}
+
+// apiResponse is the envelope every Wasmify endpoint wraps its payload in
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error,omitempty"`
+	Code    string          `json:"code,omitempty"`
+	// Details carries structured context for some error codes
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// envelope is a decoded apiResponse whose payload and error details are
+// decoded on demand
+type envelope struct {
+	success bool
+	err     string
+	code    string
+	decode  func(out interface{}) error
+	details func(out interface{}) error
+}
+
+// decodeEnvelope decodes a response body with codec. JSON keeps the
+// payload raw until it is needed. Other codecs decode the envelope into
+// generic values, which are bridged through JSON for the typed decode so
+// json struct tags apply whatever the wire format.
+func decodeEnvelope(codec Codec, body []byte) (*envelope, error) {
+	if isJSONCodec(codec) {
+		var resp apiResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		return &envelope{
+			success: resp.Success,
+			err:     resp.Error,
+			code:    resp.Code,
+			decode:  rawDecoder(resp.Data),
+			details: rawDecoder(resp.Details),
+		}, nil
+	}
+
+	var v interface{}
+	if err := codec.Decode(body, &v); err != nil {
+		return nil, err
+	}
+	resp, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an envelope object, got %T", v)
+	}
+	env := &envelope{
+		decode:  genericDecoder(resp["data"]),
+		details: genericDecoder(resp["details"]),
+	}
+	env.success, _ = resp["success"].(bool)
+	env.err, _ = resp["error"].(string)
+	env.code, _ = resp["code"].(string)
+	return env, nil
+}
+
+// rawDecoder decodes a raw JSON field on demand; a missing field leaves
+// out untouched
+func rawDecoder(raw json.RawMessage) func(out interface{}) error {
+	return func(out interface{}) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return json.Unmarshal(raw, out)
+	}
+}
+
+// genericDecoder maps a generically decoded field onto out through JSON
+func genericDecoder(v interface{}) func(out interface{}) error {
+	return func(out interface{}) error {
+		if v == nil {
+			return nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, out)
+	}
+}
+
+// newJSONRequest builds a request whose body is v encoded with the
+// client's codec, JSON unless Config.Codec says otherwise. For other codecs
+// v is first reduced to generic values through JSON, so json struct tags
+// decide the field names.
+func (c *Client) newJSONRequest(op, method, path string, v interface{}) (*request, error) {
+	if !isJSONCodec(c.codec) {
+		generic, err := normalizeJSON(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		v = generic
+	}
+
+	body, contentType, err := c.codec.Encode(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return &request{
+		op:          op,
+		method:      method,
+		path:        path,
+		body:        body,
+		contentType: contentType,
+	}, nil
+}
+
+// newHTTPRequest builds the HTTP request for a single attempt of r
+func (c *Client) newHTTPRequest(ctx context.Context, r *request) (*http.Request, error) {
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	length := int64(-1)
+	if r.stream != nil {
+		rc, n, err := r.stream()
+		if err != nil {
+			return nil, err
+		}
+		// streamed bodies are module uploads and build sources
+		body, length = throttle(ctx, rc, c.uploadLimit), n
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, c.config.APIURL+r.path, body)
+	if err != nil {
+		if rc, ok := body.(io.Closer); ok {
+			rc.Close()
+		}
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if length >= 0 {
+		req.ContentLength = length
+	}
+
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	if c.accept != "" && req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", c.accept)
+	}
+	switch {
+	case c.tokens != nil:
+		tok, err := c.tokens.Token()
+		if err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, fmt.Errorf("failed to get token: %w", err)
+		}
+		tok.SetAuthHeader(req)
+	case c.config.APIKeyFunc != nil:
+		key, err := c.config.APIKeyFunc()
+		if err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, fmt.Errorf("failed to get API key: %w", err)
+		}
+		if key == "" {
+			key = c.config.APIKey
+		}
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	case c.config.APIKey != "":
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	return req, nil
+}
+
+// isPartialContent reports whether resp answers a range request with the
+// requested range
+func isPartialContent(r *request, resp *http.Response) bool {
+	return resp.StatusCode == http.StatusPartialContent && r.header.Get("Range") != ""
+}
+
+// do sends r and hands a successful response to handle, retrying transient
+// failures according to the effective retry policy. handle runs inside the
+// attempt so the response body is closed before any retry.
+func (c *Client) do(ctx context.Context, r *request, opts []CallOption, handle func(*http.Response) error) error {
+	ctx, release, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	o := c.newCallOptions(opts)
+	if o.correlationID == "" {
+		o.correlationID = newCorrelationID()
+	}
+
+	refreshedKey := false
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("%s failed waiting for the rate limit: %w", r.op, err)
+			}
+		}
+		err := c.attempt(ctx, r, o, attempt, handle)
+		if err != nil && ctx.Err() != nil && c.aborted() {
+			return fmt.Errorf("%s aborted: %w", r.op, ErrClientClosed)
+		}
+		if IsUnauthorized(err) && c.tokens == nil && c.config.APIKeyFunc != nil && !refreshedKey && !r.oneShot && ctx.Err() == nil {
+			// the key may have rotated since it was fetched; the second
+			// try asks APIKeyFunc again and is not counted as a retry
+			refreshedKey = true
+			attempt--
+			continue
+		}
+		if err == nil || r.oneShot || attempt >= o.retry.MaxRetries || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		delay, ok := o.retry.retryDelay(attempt, err)
+		if !ok || sleepContext(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// streamingKey marks the context of a streaming request, so the debug
+// transport does not hold back its body
+type streamingKey struct{}
+
+// attemptContext bounds a single attempt by Config.Timeout. The timeout
+// is layered on ctx, so a caller's earlier deadline still wins and a later
+// one is cut short. Streaming requests, and transfers paced by a bandwidth
+// limit, which may rightly run past Timeout, are left to ctx alone.
+func (c *Client) attemptContext(ctx context.Context, r *request) (context.Context, context.CancelFunc) {
+	if r.streaming {
+		return context.WithCancel(context.WithValue(ctx, streamingKey{}, true))
+	}
+	if c.config.Timeout <= 0 || c.throttled(r) {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.config.Timeout)
+}
+
+// throttled reports whether the body of r is paced by a bandwidth limit
+func (c *Client) throttled(r *request) bool {
+	return (r.stream != nil && c.uploadLimit != nil) || (r.download && c.downloadLimit != nil)
+}
+
+// cleanupTimeout bounds cleanup work when Config.Timeout is disabled
+const cleanupTimeout = 30 * time.Second
+
+// cleanupContext returns a context for best-effort work done after the
+// caller's own context has ended, such as cancelling an execution it
+// abandoned or deleting a module it uploaded. It is bounded by
+// Config.Timeout, or by cleanupTimeout when that is disabled, as a
+// negative timeout would expire it at once.
+func (c *Client) cleanupContext() (context.Context, context.CancelFunc) {
+	timeout := c.config.Timeout
+	if timeout <= 0 {
+		timeout = cleanupTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// isTimeout reports whether a failed round trip ran out of time, either at
+// the deadline of ctx or at a transport timeout
+func isTimeout(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// attempt performs a single round trip of r
+func (c *Client) attempt(ctx context.Context, r *request, o *callOptions, n int, handle func(*http.Response) error) (err error) {
+	correlationID := o.correlationID
+	actx, cancel := c.attemptContext(ctx, r)
+	defer cancel()
+
+	req, err := c.newHTTPRequest(actx, r)
+	if err != nil {
+		return err
+	}
+	if correlationID != "" {
+		req.Header.Set(correlationIDHeader, correlationID)
+	}
+
+	ev := RequestEvent{Op: r.op, Method: r.method, Path: r.path, Attempt: n, CorrelationID: correlationID}
+	start := time.Now()
+	c.lastRequest.Store(start.UnixNano())
+	defer func() {
+		ev.Duration = time.Since(start)
+		ev.Err = err
+		c.observe(ctx, ev)
+	}()
+
+	if c.config.Logger != nil {
+		c.config.Logger.LogRequest(req.Method, req.URL.String())
+	}
+	resp, err := c.httpClient.Do(req)
+	if c.config.Logger != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.config.Logger.LogResponse(status, time.Since(start))
+	}
+	if err != nil {
+		var be *bodyError
+		if errors.As(err, &be) {
+			return be.err
+		}
+		var are *authRedirectError
+		if errors.As(err, &are) {
+			return fmt.Errorf("%s failed: %w", r.op, are)
+		}
+		err = fmt.Errorf("failed to send request: %w", err)
+		if isTimeout(actx, err) {
+			err = &networkTimeoutError{err}
+		}
+		return &retryableError{err}
+	}
+	defer resp.Body.Close()
+	ev.StatusCode = resp.StatusCode
+	c.recordClockSkew(resp, start, time.Now())
+
+	if err := decodeContentEncoding(resp); err != nil {
+		return &retryableError{err}
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && !isPartialContent(r, resp) {
+		err := newAPIError(r.op, resp, c.codec)
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			err.ClockSkew = c.significantClockSkew()
+		}
+		if o.retry.retryableStatus(resp.StatusCode) {
+			return &retryableError{err}
+		}
+		return err
+	}
+	if redirectedToHTML(req, resp) {
+		return fmt.Errorf("%s failed: %w", r.op, &authRedirectError{location: resp.Request.URL})
+	}
+
+	if r.onResponse != nil {
+		r.onResponse(resp)
+	}
+	return handle(resp)
+}
+
+// doJSON sends r and decodes the data field of the response envelope into
+// out, which may be nil when the payload is not needed
+func (c *Client) doJSON(ctx context.Context, r *request, opts []CallOption, out interface{}) error {
+	return c.do(ctx, r, opts, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusNoContent {
+			// nothing to decode; out keeps its zero value
+			return nil
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			if isTruncation(err) {
+				return truncatedError(int64(len(body)), err)
+			}
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+
+		result, err := decodeEnvelope(c.codec, body)
+		if err != nil {
+			if isTruncation(err) {
+				return truncatedError(int64(len(body)), err)
+			}
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		if !result.success {
+			return fmt.Errorf("%s failed", r.op)
+		}
+
+		if out == nil {
+			return nil
+		}
+		if err := result.decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	})
+}
+
+// doJSONList sends r and decodes the data array of the response envelope
+// one element at a time, so large lists are never held in memory as a
+// whole. each is called for every element with a function that decodes
+// it. Elements already handed to each stay delivered if the envelope later
+// turns out to be malformed.
+func (c *Client) doJSONList(ctx context.Context, r *request, opts []CallOption, each func(decode func(out interface{}) error) error) error {
+	if !isJSONCodec(c.codec) {
+		var items []interface{}
+		if err := c.doJSON(ctx, r, opts, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := each(genericDecoder(item)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return c.do(ctx, r, opts, func(resp *http.Response) error {
+		body := &countingReader{r: resp.Body}
+		delivered := false
+		err := decodeListEnvelope(body, r.op, func(decode func(out interface{}) error) error {
+			if err := each(decode); err != nil {
+				return err
+			}
+			delivered = true
+			return nil
+		})
+		if err != nil && isTruncation(err) {
+			err = truncatedError(body.n, err)
+			if delivered {
+				// elements already handed out would be repeated
+				err = err.(*retryableError).err
+			}
+		}
+		return err
+	})
+}
+
+// decodeListEnvelope walks a JSON envelope token by token, streaming the
+// elements of its data array to each
+func decodeListEnvelope(body io.Reader, op string, each func(decode func(out interface{}) error) error) error {
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	var success, sawSuccess bool
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		key, _ := tok.(string)
+
+		switch {
+		case key == "success":
+			err = dec.Decode(&success)
+			sawSuccess = true
+		case key == "data" && (success || !sawSuccess):
+			err = decodeListData(dec, each)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !success {
+		return fmt.Errorf("%s failed", op)
+	}
+	return nil
+}
+
+// decodeListData streams the elements of a data array; null is an empty
+// list
+func decodeListData(dec *json.Decoder, each func(decode func(out interface{}) error) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("failed to decode response: expected data array, got %v", tok)
+	}
+
+	for dec.More() {
+		if err := each(dec.Decode); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("failed to decode response: expected %v, got %v", want, tok)
+	}
+	return nil
+}
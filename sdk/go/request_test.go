@@ -0,0 +1,139 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAttemptContextDeadline(t *testing.T) {
+	const timeout = time.Minute
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		ctxAfter  time.Duration // zero leaves ctx without a deadline
+		streaming bool
+		want      time.Duration // zero means no deadline
+	}{
+		{name: "timeout only", timeout: timeout, want: timeout},
+		{name: "context earlier", timeout: timeout, ctxAfter: time.Second, want: time.Second},
+		{name: "context later", timeout: timeout, ctxAfter: time.Hour, want: timeout},
+		{name: "context only", timeout: -1, ctxAfter: time.Hour, want: time.Hour},
+		{name: "neither", timeout: -1},
+		{name: "streaming", timeout: timeout, ctxAfter: time.Hour, streaming: true, want: time.Hour},
+		{name: "streaming without deadline", timeout: timeout, streaming: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(Config{APIURL: "http://127.0.0.1", Timeout: tt.timeout})
+
+			start := time.Now()
+			ctx := context.Background()
+			if tt.ctxAfter > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxAfter)
+				defer cancel()
+			}
+			actx, cancel := c.attemptContext(ctx, &request{streaming: tt.streaming})
+			defer cancel()
+			end := time.Now()
+
+			deadline, ok := actx.Deadline()
+			if tt.want == 0 {
+				if ok {
+					t.Fatalf("got deadline in %v, want none", deadline.Sub(start))
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("got no deadline, want one in %v", tt.want)
+			}
+			if deadline.Before(start.Add(tt.want)) || deadline.After(end.Add(tt.want)) {
+				t.Errorf("got deadline in %v, want %v", deadline.Sub(start), tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestHonorsContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := newTestClient(t, Config{Timeout: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetModuleContext(ctx, "mod-1")
+	if err == nil {
+		t.Fatal("got no error from a request past its deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v, want it cut short by the context deadline", elapsed)
+	}
+}
+
+// truncatingHandler sends half of a module envelope and then drops the
+// connection, failures times over, before answering in full
+func truncatingHandler(t *testing.T, failures int, calls *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(atomic.AddInt32(calls, 1)) > failures {
+			writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod"})
+			return
+		}
+		body := `{"success":true,"data":{"id":"mod-1","name":"mod"}}`
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, body[:len(body)/2])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("failed to hijack connection: %v", err)
+			return
+		}
+		conn.Close()
+	}
+}
+
+func TestTruncatedResponseIsRetried(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, Config{MaxRetries: 1, RetryBackoff: time.Millisecond}, truncatingHandler(t, 1, &calls))
+
+	module, err := c.GetModule("mod-1")
+	if err != nil {
+		t.Fatalf("GetModule: %v", err)
+	}
+	if module.ID != "mod-1" {
+		t.Errorf("got module %q, want mod-1", module.ID)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+}
+
+func TestTruncatedResponseError(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, Config{}, truncatingHandler(t, 1, &calls))
+
+	_, err := c.GetModule("mod-1")
+	if err == nil {
+		t.Fatal("got no error from a truncated response")
+	}
+	if !strings.Contains(err.Error(), "response truncated after") {
+		t.Errorf("got error %q, want it to report the truncation", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got error %q, want it to wrap io.ErrUnexpectedEOF", err)
+	}
+}
@@ -0,0 +1,84 @@
+package wasmify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ResultAs returns r.Result as a T. Numbers convert to any numeric T as
+// long as the value fits, so a JSON float64 of 3 reads as int; other
+// results, such as maps for a struct T, are decoded through JSON, and a
+// nil result gives the zero T. An error names both types when the result
+// does not convert.
+func ResultAs[T any](r *ExecutionResult) (T, error) {
+	var zero T
+	if r == nil {
+		return zero, errors.New("no execution result")
+	}
+	if r.Result == nil {
+		return zero, nil
+	}
+	if v, ok := r.Result.(T); ok {
+		return v, nil
+	}
+
+	typ := reflect.TypeOf(&zero).Elem()
+	out := reflect.New(typ).Elem()
+	if f, integer, ok := argNumber(r.Result); ok && isNumericKind(out.Kind()) {
+		if err := setNumber(out, f, integer); err != nil {
+			return zero, fmt.Errorf("cannot convert result %v to %s: %w", r.Result, typ, err)
+		}
+		return out.Interface().(T), nil
+	}
+
+	data, err := json.Marshal(r.Result)
+	if err != nil {
+		return zero, fmt.Errorf("cannot convert result of type %T to %s: %w", r.Result, typ, err)
+	}
+	if err := json.Unmarshal(data, out.Addr().Interface()); err != nil {
+		return zero, fmt.Errorf("cannot convert result of type %T to %s: %w", r.Result, typ, err)
+	}
+	return out.Interface().(T), nil
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setNumber stores f in the numeric value v, failing if it would lose the
+// fraction or overflow
+func setNumber(v reflect.Value, f float64, integer bool) error {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if v.OverflowFloat(f) {
+			return errors.New("out of range")
+		}
+		v.SetFloat(f)
+		return nil
+	}
+	if !integer {
+		return errors.New("not an integer")
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f < math.MinInt64 || f >= math.MaxInt64 || v.OverflowInt(int64(f)) {
+			return errors.New("out of range")
+		}
+		v.SetInt(int64(f))
+	default:
+		if f < 0 || f >= math.MaxUint64 || v.OverflowUint(uint64(f)) {
+			return errors.New("out of range")
+		}
+		v.SetUint(uint64(f))
+	}
+	return nil
+}
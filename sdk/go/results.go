@@ -0,0 +1,109 @@
+package wasmify
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats supported by WriteResults
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// resultColumns is the CSV header written by WriteResults
+var resultColumns = []string{"success", "result", "executionTime", "memoryUsed", "queueTime", "coldStart", "coldStartTime", "error", "warnings"}
+
+// WriteResults writes execution results to w as a JSON array, as
+// newline-delimited JSON or as CSV with a header row. In CSV, scalar
+// results are written as is and other results, like warnings, as JSON in
+// their cell.
+func WriteResults(w io.Writer, results []ExecutionResult, format string) error {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		if results == nil {
+			results = []ExecutionResult{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for i := range results {
+			if err := enc.Encode(&results[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatCSV:
+		return writeResultsCSV(w, results)
+	}
+	return fmt.Errorf("unsupported results format %q", format)
+}
+
+func writeResultsCSV(w io.Writer, results []ExecutionResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(resultColumns); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		value, err := csvCell(r.Result)
+		if err != nil {
+			return err
+		}
+		warnings := ""
+		if len(r.Warnings) > 0 {
+			if warnings, err = csvCell(r.Warnings); err != nil {
+				return err
+			}
+		}
+
+		if err := cw.Write([]string{
+			strconv.FormatBool(r.Success),
+			value,
+			strconv.FormatFloat(r.ExecutionTime, 'f', -1, 64),
+			strconv.FormatInt(r.MemoryUsed, 10),
+			strconv.FormatFloat(r.QueueTime, 'f', -1, 64),
+			strconv.FormatBool(r.ColdStart),
+			strconv.FormatFloat(r.ColdStartTime, 'f', -1, 64),
+			r.Error,
+			warnings,
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell renders a result for a CSV cell: scalars directly, anything else
+// as JSON
+func csvCell(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		return v.String(), nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(data), nil
+}
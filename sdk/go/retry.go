@@ -0,0 +1,135 @@
+package wasmify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = 200 * time.Millisecond
+	maxRetryBackoff     = 10 * time.Second
+	// maxRetryAfter is the longest Retry-After delay honoured; a server
+	// asking for more fails the request instead of stalling it
+	maxRetryAfter = time.Minute
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of additional attempts after the first one.
+	// Zero disables retries.
+	MaxRetries int
+	// Backoff is the delay before the first retry. It doubles on every
+	// subsequent attempt and defaults to 200ms. A Retry-After header on
+	// the failed response replaces it.
+	Backoff time.Duration
+	// RetryableStatus reports whether a response status is transient.
+	// Nil retries 429, 502, 503 and 504.
+	RetryableStatus func(code int) bool
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	if p.RetryableStatus != nil {
+		return p.RetryableStatus(code)
+	}
+	return isRetryableStatus(code)
+}
+
+// retryDelay returns how long to wait before retry number attempt after
+// err, and false if the server asked for a longer wait than is honoured
+func (p RetryPolicy) retryDelay(attempt int, err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, apiErr.RetryAfter <= maxRetryAfter
+	}
+	return p.backoff(attempt), true
+}
+
+// backoff returns the jittered delay to wait before retry number attempt
+// (starting at zero)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	if d <= 0 {
+		d = defaultRetryBackoff
+	}
+	for i := 0; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	// Equal jitter keeps at least half the delay while spreading out clients
+	// that failed at the same moment.
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryableError marks an error as transient so the request may be retried
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+// isRetryable reports whether err was marked as transient
+func isRetryable(err error) bool {
+	var re *retryableError
+	return errors.As(err, &re)
+}
+
+// isRetryableStatus reports whether a response status indicates a transient
+// server-side condition
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// compileErrorPatterns compiles ExecutionConfig.RetryOnErrorPatterns
+func compileErrorPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry error pattern %q: %w", p, err)
+		}
+		res[i] = re
+	}
+	return res, nil
+}
+
+// matchesAny reports whether msg is non-empty and matches one of res
+func matchesAny(res []*regexp.Regexp, msg string) bool {
+	if msg == "" {
+		return false
+	}
+	for _, re := range res {
+		if re.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateSnapshot instantiates a module, runs its initialization and saves
+// the resulting state. Executions that set ExecutionConfig.SnapshotID to
+// the returned ID start from that state instead of initializing again,
+// which pays off for modules with expensive setup such as loading a model.
+// Servers without snapshot support yield ErrFeatureUnavailable.
+func (c *Client) CreateSnapshot(ctx context.Context, moduleID string, opts ...CallOption) (string, error) {
+	if err := c.requireFeature(ctx, FeatureSnapshots, opts); err != nil {
+		return "", err
+	}
+
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return "", err
+	}
+
+	req := &request{
+		op:     "create snapshot",
+		method: http.MethodPost,
+		path:   "/modules/" + url.PathEscape(moduleID) + "/snapshots",
+	}
+
+	var data struct {
+		ID string `json:"id"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return "", err
+	}
+	return data.ID, nil
+}
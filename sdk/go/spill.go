@@ -0,0 +1,149 @@
+package wasmify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultSpillThreshold is the amount of data a SpillBuffer keeps in memory
+// before it moves to a temporary file
+const DefaultSpillThreshold = 8 << 20
+
+var errSpillWriteAfterRead = errors.New("spill buffer: write after read")
+
+// SpillBuffer collects a payload in memory and moves it to a temporary file
+// once it grows past a threshold, so small payloads stay fast and large
+// ones cannot exhaust memory. Write the payload first, then read it back
+// through the io.ReadSeeker methods. Close releases the temporary file.
+type SpillBuffer struct {
+	threshold int64
+	size      int64
+
+	mem  bytes.Buffer
+	file *os.File
+	// path is set while the temporary file still has a name to remove
+	path string
+
+	reader io.ReadSeeker
+}
+
+// NewSpillBuffer returns a buffer that spills to disk beyond threshold
+// bytes; zero or less uses DefaultSpillThreshold
+func NewSpillBuffer(threshold int64) *SpillBuffer {
+	if threshold <= 0 {
+		threshold = DefaultSpillThreshold
+	}
+	return &SpillBuffer{threshold: threshold}
+}
+
+// Write appends p to the buffer. It fails once reading has started.
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.reader != nil {
+		return 0, errSpillWriteAfterRead
+	}
+
+	if b.file == nil && b.size+int64(len(p)) > b.threshold {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// spill moves the buffered data to a new temporary file
+func (b *SpillBuffer) spill() error {
+	file, err := os.CreateTemp("", "wasmify-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spill file: %w", err)
+	}
+
+	// Unlink right away where the OS allows it, so the file disappears
+	// with the process even if Close is never called
+	if os.Remove(file.Name()) != nil {
+		b.path = file.Name()
+	}
+	b.file = file
+
+	if _, err := b.mem.WriteTo(file); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	b.mem = bytes.Buffer{}
+	return nil
+}
+
+// Read reads from the start of the buffered payload on the first call and
+// continues from the current offset afterwards
+func (b *SpillBuffer) Read(p []byte) (int, error) {
+	r, err := b.readSeeker()
+	if err != nil {
+		return 0, err
+	}
+	return r.Read(p)
+}
+
+// Seek sets the offset of the next Read
+func (b *SpillBuffer) Seek(offset int64, whence int) (int64, error) {
+	r, err := b.readSeeker()
+	if err != nil {
+		return 0, err
+	}
+	return r.Seek(offset, whence)
+}
+
+func (b *SpillBuffer) readSeeker() (io.ReadSeeker, error) {
+	if b.reader != nil {
+		return b.reader, nil
+	}
+
+	if b.file == nil {
+		b.reader = bytes.NewReader(b.mem.Bytes())
+		return b.reader, nil
+	}
+
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind spill file: %w", err)
+	}
+	b.reader = b.file
+	return b.reader, nil
+}
+
+// Size returns the number of bytes written
+func (b *SpillBuffer) Size() int64 {
+	return b.size
+}
+
+// Spilled reports whether the payload was moved to a temporary file
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// Close discards the payload and removes the temporary file, if any
+func (b *SpillBuffer) Close() error {
+	b.mem = bytes.Buffer{}
+	b.reader = nil
+	if b.file == nil {
+		return nil
+	}
+
+	err := b.file.Close()
+	b.file = nil
+	if b.path != "" {
+		if rerr := os.Remove(b.path); rerr != nil && err == nil {
+			err = rerr
+		}
+		b.path = ""
+	}
+	return err
+}
@@ -0,0 +1,226 @@
+package wasmify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// executionIDHeader names the execution a streaming response belongs to
+const executionIDHeader = "X-Execution-ID"
+
+// Output streams carried by OutputEvent
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputEvent is a chunk of output a module wrote while executing
+type OutputEvent struct {
+	// Stream is StreamStdout or StreamStderr
+	Stream string
+	Data   []byte
+}
+
+// ExecutionStream is an execution whose output arrives as it is written
+type ExecutionStream struct {
+	// Events delivers output in the order it was written and is closed
+	// when the execution ends. It must be drained, or ctx cancelled, for
+	// the execution to finish.
+	Events <-chan OutputEvent
+
+	done   chan struct{}
+	result *ExecutionResult
+	err    error
+}
+
+// Wait blocks until the execution has ended and returns its result
+func (s *ExecutionStream) Wait() (*ExecutionResult, error) {
+	<-s.done
+	return s.result, s.err
+}
+
+// sseEvent is one server-sent event
+type sseEvent struct {
+	name string
+	data string
+}
+
+// ExecuteModuleStream executes a function like Execute, delivering the
+// module's stdout and stderr on the returned stream while it runs. It
+// needs a server with FeatureStreaming. Output already delivered is never
+// replayed, so the execution is not retried once it has started.
+// Cancelling ctx stops reading and also asks the server, through
+// CancelExecution, to abort the execution so it does not run on unseen.
+func (c *Client) ExecuteModuleStream(ctx context.Context, moduleID, functionName string, args []interface{}, config ExecutionConfig, opts ...CallOption) (*ExecutionStream, error) {
+	if err := c.requireFeature(ctx, FeatureStreaming, opts); err != nil {
+		return nil, err
+	}
+
+	opts, correlationID := c.withCorrelationID(opts)
+	moduleID, functionName, config, err := c.prepareExecution(ctx, moduleID, functionName, config, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	requestData, err := newExecutionRequestData(functionName, args, config)
+	if err != nil {
+		return nil, err
+	}
+	requestData["moduleId"] = moduleID
+
+	req, err := c.newJSONRequest("streaming execution", http.MethodPost, "/wasm/execute/stream", requestData)
+	if err != nil {
+		return nil, err
+	}
+	req.header = http.Header{"Accept": {"text/event-stream"}}
+	if config.Priority != "" {
+		req.header.Set(priorityHeader, string(config.Priority))
+	}
+	req.streaming = true
+	var executionID string
+	req.onResponse = func(resp *http.Response) {
+		if id := resp.Header.Get(correlationIDHeader); id != "" {
+			correlationID = id
+		}
+		executionID = resp.Header.Get(executionIDHeader)
+	}
+
+	events := make(chan OutputEvent)
+	s := &ExecutionStream{Events: events, done: make(chan struct{})}
+
+	go func() {
+		defer close(s.done)
+		err := c.do(ctx, req, opts, func(resp *http.Response) error {
+			return readSSE(resp.Body, func(ev sseEvent) error {
+				switch ev.name {
+				case "start":
+					var start struct {
+						ExecutionID string `json:"executionId"`
+					}
+					if json.Unmarshal([]byte(ev.data), &start) == nil && start.ExecutionID != "" {
+						executionID = start.ExecutionID
+					}
+				case StreamStdout, StreamStderr:
+					select {
+					case events <- OutputEvent{Stream: ev.name, Data: []byte(ev.data)}:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				case "result":
+					var p executionPayload
+					if err := json.Unmarshal([]byte(ev.data), &p); err != nil {
+						return fmt.Errorf("failed to decode result: %w", err)
+					}
+					result, err := p.toResult(config)
+					if err != nil {
+						return err
+					}
+					result.CorrelationID = correlationID
+					s.result = result
+					return p.limitError(result)
+				case "error":
+					var e struct {
+						Message string `json:"message"`
+						Code    string `json:"code"`
+					}
+					if json.Unmarshal([]byte(ev.data), &e) != nil || e.Message == "" {
+						e.Message = ev.data
+					}
+					return &APIError{Op: req.op, StatusCode: resp.StatusCode, Status: resp.Status, Message: e.Message, Code: e.Code}
+				}
+				// unknown events are ignored so servers can add new ones
+				return nil
+			})
+		})
+		close(events)
+		if ctx.Err() != nil && s.result == nil && executionID != "" {
+			c.abortExecution(executionID, opts)
+		}
+		if err == nil && s.result == nil {
+			err = fmt.Errorf("execution stream ended without a result: %w", io.ErrUnexpectedEOF)
+		}
+		s.err = err
+	}()
+
+	return s, nil
+}
+
+// CancelExecution asks the server to abort a running execution, such as
+// one started by ExecuteModuleStream or ExecuteModuleAsync. Cancelling an
+// execution that has already finished is not an error.
+func (c *Client) CancelExecution(ctx context.Context, executionID string, opts ...CallOption) error {
+	req := &request{
+		op:     "cancel execution",
+		method: http.MethodPost,
+		path:   "/wasm/executions/" + url.PathEscape(executionID) + "/cancel",
+	}
+
+	err := c.doJSON(ctx, req, opts, nil)
+	if hasStatus(err, http.StatusConflict) {
+		return nil
+	}
+	return err
+}
+
+// abortExecution cancels an execution whose caller has gone away. It uses
+// a cleanup context, as the caller's is already done, and is best effort:
+// the server also stops executions when their time budget runs out.
+func (c *Client) abortExecution(executionID string, opts []CallOption) {
+	ctx, cancel := c.cleanupContext()
+	defer cancel()
+	_ = c.CancelExecution(ctx, executionID, opts...)
+}
+
+// readSSE parses a text/event-stream body, calling each for every event
+// until the body ends or each fails
+func readSSE(body io.Reader, each func(sseEvent) error) error {
+	r := bufio.NewReader(body)
+	var ev sseEvent
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read event stream: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if data != nil {
+				ev.data = strings.Join(data, "\n")
+				if ev.name == "" {
+					ev.name = "message"
+				}
+				if err := each(ev); err != nil {
+					return err
+				}
+			}
+			ev, data = sseEvent{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			ev.name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
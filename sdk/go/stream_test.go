@@ -0,0 +1,66 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecuteModuleStreamCancelsOnServer(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"default timeout", 0},
+		{"timeout disabled", -1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var cancelled int32
+			c := newTestClient(t, Config{Timeout: tt.timeout}, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/modules/mod-1":
+					writeData(t, w, map[string]interface{}{"id": "mod-1", "name": "mod"})
+				case "/capabilities":
+					writeData(t, w, Capabilities{Features: []string{FeatureStreaming}})
+				case "/wasm/execute/stream":
+					w.Header().Set("Content-Type", "text/event-stream")
+					w.Header().Set(executionIDHeader, "exec-1")
+					w.Write([]byte("event: stdout\ndata: working\n\n"))
+					w.(http.Flusher).Flush()
+					<-r.Context().Done()
+				case "/wasm/executions/exec-1/cancel":
+					atomic.AddInt32(&cancelled, 1)
+					writeData(t, w, nil)
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					http.NotFound(w, r)
+				}
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s, err := c.ExecuteModuleStream(ctx, "mod-1", "run", nil, ExecutionConfig{})
+			if err != nil {
+				t.Fatalf("ExecuteModuleStream: %v", err)
+			}
+			select {
+			case ev := <-s.Events:
+				if string(ev.Data) != "working" {
+					t.Errorf("got output %q, want working", ev.Data)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("got no output from the stream")
+			}
+
+			cancel()
+			if _, err := s.Wait(); err == nil {
+				t.Error("got no error from a cancelled stream")
+			}
+			if n := atomic.LoadInt32(&cancelled); n != 1 {
+				t.Errorf("server got %d cancel requests, want 1", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package wasmify
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// bandwidthLimiter is a token bucket of bytes shared by every transfer in
+// one direction, so concurrent transfers split the rate between them
+type bandwidthLimiter struct {
+	rate float64 // bytes per second
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newBandwidthLimiter returns a limiter for bytesPerSec, or nil when it is
+// not positive and transfers are unlimited
+func newBandwidthLimiter(bytesPerSec int64) *bandwidthLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{rate: float64(bytesPerSec), tokens: float64(bytesPerSec), last: time.Now()}
+}
+
+// chunk is the most a single read may move, a tenth of a second of
+// traffic, so the rate stays smooth instead of bursting
+func (l *bandwidthLimiter) chunk() int {
+	if n := int(l.rate / 10); n > 1 {
+		return n
+	}
+	return 1
+}
+
+// wait takes n bytes from the bucket, sleeping until they are available
+// or ctx is done. The bucket may go into debt, which later callers pay
+// off, so a large read is never starved by small ones.
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		// at most one second of idle time is saved up
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	debt := l.tokens
+	l.mu.Unlock()
+
+	if debt >= 0 {
+		return nil
+	}
+	return sleepContext(ctx, time.Duration(-debt/l.rate*float64(time.Second)))
+}
+
+// throttledReader paces reads from r to its limiter
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *bandwidthLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if err := t.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if max := t.l.chunk(); len(p) > max {
+		p = p[:max]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if werr := t.l.wait(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// throttle wraps rc so reading it is paced by l; with no limiter rc is
+// returned as is
+func throttle(ctx context.Context, rc io.ReadCloser, l *bandwidthLimiter) io.ReadCloser {
+	if l == nil {
+		return rc
+	}
+	return readCloser{Reader: &throttledReader{ctx: ctx, r: rc, l: l}, Closer: rc}
+}
@@ -0,0 +1,237 @@
+package wasmify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// uploadBody streams a module upload as multipart form data. The file is
+// read straight into an io.Pipe and hashed on the way through, so it is
+// neither buffered in memory nor read twice. The length of the body is
+// known up front, so it is sent with a Content-Length rather than
+// chunked. open is called once per attempt; the hash of the last attempt
+// is available from sum once the request has completed.
+type uploadBody struct {
+	filePath string
+	fields   [][2]string
+	boundary string
+
+	// precomputed is the caller-supplied digest, if any, and trust skips
+	// hashing in favour of it
+	precomputed string
+	trust       bool
+
+	// last is the state of the most recently opened attempt
+	last *uploadAttempt
+
+	progress ProgressFunc
+}
+
+type uploadAttempt struct {
+	done chan struct{}
+	hash hash.Hash
+	err  error
+}
+
+func newUploadBody(filePath, name, version string, options UploadOptions) (*uploadBody, error) {
+	fields := [][2]string{{"name", name}, {"version", version}}
+	if len(options.Metadata) > 0 {
+		metadata, err := json.Marshal(options.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metadata: %w", err)
+		}
+		fields = append(fields, [2]string{"metadata", string(metadata)})
+	}
+
+	if options.DefaultFunction != "" {
+		fields = append(fields, [2]string{"defaultFunction", options.DefaultFunction})
+	}
+	defaults, err := encodeExecutionDefaults(options.DefaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	if defaults != "" {
+		fields = append(fields, [2]string{"defaultConfig", defaults})
+	}
+
+	precomputed := normalizeDigest(options.PrecomputedSHA256)
+	if precomputed != "" {
+		fields = append(fields, [2]string{"sha256", precomputed})
+	}
+
+	return &uploadBody{
+		filePath: filePath,
+		fields:   fields,
+		// a fixed boundary keeps the content type valid across attempts
+		boundary:    multipart.NewWriter(io.Discard).Boundary(),
+		precomputed: precomputed,
+		trust:       precomputed != "" && options.SkipValidation,
+		progress:    options.Progress,
+	}, nil
+}
+
+func (b *uploadBody) contentType() string {
+	return "multipart/form-data; boundary=" + b.boundary
+}
+
+// open starts streaming a fresh copy of the body and returns its length.
+// The writing goroutine exits once the body is fully read or the reader
+// is closed, whichever comes first.
+func (b *uploadBody) open() (io.ReadCloser, int64, error) {
+	file, err := os.Open(b.filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	overhead, err := b.overhead()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+
+	pr, pw := io.Pipe()
+	a := &uploadAttempt{done: make(chan struct{}), hash: sha256.New()}
+	b.last = a
+
+	go func() {
+		defer close(a.done)
+		defer file.Close()
+
+		var content io.Reader = file
+		if !b.trust {
+			content = io.TeeReader(file, a.hash)
+		}
+		var progress *progressReader
+		if b.progress != nil {
+			progress = &progressReader{r: content, total: info.Size(), fn: b.progress}
+			content = progress
+		}
+		a.err = b.write(pw, content, a.verify)
+		if a.err == nil && progress != nil {
+			progress.finish()
+		}
+		if a.err != nil {
+			pw.CloseWithError(&bodyError{a.err})
+			return
+		}
+		pw.Close()
+	}()
+
+	return pr, overhead + info.Size(), nil
+}
+
+// overhead returns the size of the multipart framing around the file
+// content, which is fixed because the boundary and fields are
+func (b *uploadBody) overhead() (int64, error) {
+	var cw countingWriter
+	noVerify := func(*uploadBody) error { return nil }
+	if err := b.write(&cw, strings.NewReader(""), noVerify); err != nil {
+		return 0, err
+	}
+	return cw.n, nil
+}
+
+// progressReader reports the bytes read through it to a ProgressFunc
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.sent += int64(n)
+		pr.fn(pr.sent, pr.total)
+	}
+	return n, err
+}
+
+// finish reports the final total unless the last report already did, so
+// callers always see 100%, even for an empty file
+func (pr *progressReader) finish() {
+	if pr.sent != pr.total || pr.total == 0 {
+		pr.fn(pr.total, pr.total)
+	}
+}
+
+// countingWriter discards what is written to it, counting the bytes
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
+
+// write emits the multipart body; verify runs once the file content has
+// been copied, and an error from it aborts the body before it completes
+func (b *uploadBody) write(w io.Writer, content io.Reader, verify func(*uploadBody) error) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+
+	part, err := writer.CreateFormFile("file", filepath.Base(b.filePath))
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := verify(b); err != nil {
+		return err
+	}
+
+	for _, f := range b.fields {
+		if err := writer.WriteField(f[0], f[1]); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// sum waits for the last attempt to finish streaming and returns the
+// hex-encoded SHA-256 of the file content it sent, or the precomputed
+// digest when that is trusted
+func (b *uploadBody) sum() (string, error) {
+	a := b.last
+	if a == nil {
+		return "", fmt.Errorf("upload body was never sent")
+	}
+	<-a.done
+	if a.err != nil {
+		return "", a.err
+	}
+	if b.trust {
+		return b.precomputed, nil
+	}
+	return hex.EncodeToString(a.hash.Sum(nil)), nil
+}
+
+// verify fails the attempt when the streamed content does not match the
+// supplied digest, so mismatched content is never fully uploaded
+func (a *uploadAttempt) verify(b *uploadBody) error {
+	if b.trust || b.precomputed == "" {
+		return nil
+	}
+	if sum := hex.EncodeToString(a.hash.Sum(nil)); sum != b.precomputed {
+		return fmt.Errorf("%w: %s hashes to sha256:%s, not the supplied sha256:%s", ErrDigestMismatch, b.filePath, sum, b.precomputed)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package wasmify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// versionSeparator joins a module name and version in a reference
+const versionSeparator = "@"
+
+// versionPattern matches the version numbers a reference may name, such
+// as 1, v1.2 or 1.1.0-rc.1+build.5
+var versionPattern = regexp.MustCompile(`^v?[0-9]+(\.[0-9]+){0,2}(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// ModuleVersion returns the reference for version of the module published
+// under name, e.g. "resize@1.1.0". The reference is accepted anywhere a
+// module ID is, so executions can be pinned to a version by name. Only a
+// version number such as 1.1.0 or v2 makes a reference; anything else
+// after the "@" is read as part of a plain module ID.
+func ModuleVersion(name, version string) string {
+	return name + versionSeparator + version
+}
+
+// parseVersionRef splits a "name@version" reference. ok is false for
+// ordinary module IDs and pinned references, including IDs that contain
+// an "@" not followed by a version number.
+func parseVersionRef(ref string) (name, version string, ok bool) {
+	if strings.HasPrefix(ref, pinPrefix) {
+		return "", "", false
+	}
+	i := strings.LastIndex(ref, versionSeparator)
+	if i <= 0 || !versionPattern.MatchString(ref[i+1:]) {
+		return "", "", false
+	}
+	return ref[:i], ref[i+1:], true
+}
+
+// resolveVersionRef turns a "name@version" reference into the ID of that
+// version. Other references are returned unchanged.
+func (c *Client) resolveVersionRef(ctx context.Context, ref string, opts []CallOption) (string, error) {
+	if _, _, ok := parseVersionRef(ref); !ok {
+		return ref, nil
+	}
+	module, err := c.GetModuleContext(ctx, ref, opts...)
+	if err != nil {
+		return "", err
+	}
+	return module.ID, nil
+}
+
+// ListVersions returns the versions published under a module name
+func (c *Client) ListVersions(moduleName string, opts ...CallOption) ([]string, error) {
+	return c.ListVersionsContext(context.Background(), moduleName, opts...)
+}
+
+// ListVersionsContext is like ListVersions but stops when ctx is done
+func (c *Client) ListVersionsContext(ctx context.Context, moduleName string, opts ...CallOption) ([]string, error) {
+	req := &request{
+		op:     "list versions",
+		method: http.MethodGet,
+		path:   "/modules/" + url.PathEscape(moduleName) + "/versions",
+	}
+
+	var data struct {
+		Versions []string `json:"versions"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, moduleNotFound(moduleName, err)
+	}
+	return data.Versions, nil
+}
@@ -0,0 +1,27 @@
+package wasmify
+
+import "testing"
+
+func TestParseVersionRef(t *testing.T) {
+	tests := []struct {
+		ref           string
+		name, version string
+		ok            bool
+	}{
+		{ref: "resize@1.1.0", name: "resize", version: "1.1.0", ok: true},
+		{ref: "resize@v2", name: "resize", version: "v2", ok: true},
+		{ref: "team@scope/resize@1.0.0-rc.1+build.5", name: "team@scope/resize", version: "1.0.0-rc.1+build.5", ok: true},
+		{ref: "mod-1"},
+		{ref: "alice@example"},
+		{ref: "resize@"},
+		{ref: "@1.0.0"},
+		{ref: "resize@1.2.3.4"},
+		{ref: PinModule("0000000000000000000000000000000000000000000000000000000000000000")},
+	}
+	for _, tt := range tests {
+		name, version, ok := parseVersionRef(tt.ref)
+		if name != tt.name || version != tt.version || ok != tt.ok {
+			t.Errorf("parseVersionRef(%q) = %q, %q, %v; want %q, %q, %v", tt.ref, name, version, ok, tt.name, tt.version, tt.ok)
+		}
+	}
+}
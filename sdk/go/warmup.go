@@ -0,0 +1,69 @@
+package wasmify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// warmUpPollInterval is how often WarmUp checks on instances still starting
+const warmUpPollInterval = 500 * time.Millisecond
+
+// warmUpStatus is the server's view of a warm-up request
+type warmUpStatus struct {
+	Requested int    `json:"requested"`
+	Warm      int    `json:"warm"`
+	Error     string `json:"error,omitempty"`
+}
+
+// WarmUp asks the server to pre-instantiate count instances of a module,
+// so a coming burst of executions skips cold starts, and waits until they
+// are ready. It returns how many instances are warm, which is fewer than
+// count when ctx is done first or the server could not start them all;
+// an error is returned in both cases.
+func (c *Client) WarmUp(ctx context.Context, moduleID string, count int, opts ...CallOption) (int, error) {
+	if count <= 0 {
+		return 0, fmt.Errorf("invalid warm-up count %d: must be positive", count)
+	}
+
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	path := "/modules/" + url.PathEscape(moduleID) + "/warmup"
+	req, err := c.newJSONRequest("warm-up", http.MethodPost, path, map[string]interface{}{
+		"count": count,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var status warmUpStatus
+	if err := c.doJSON(ctx, req, opts, &status); err != nil {
+		return 0, err
+	}
+
+	for status.Warm < count {
+		if status.Error != "" {
+			return status.Warm, fmt.Errorf("warm-up of %s failed with %d of %d instances ready: %s", moduleID, status.Warm, count, status.Error)
+		}
+		if err := sleepContext(ctx, warmUpPollInterval); err != nil {
+			return status.Warm, fmt.Errorf("warm-up of %s stopped with %d of %d instances ready: %w", moduleID, status.Warm, count, err)
+		}
+
+		req := &request{
+			op:     "warm-up status",
+			method: http.MethodGet,
+			path:   path,
+		}
+		var next warmUpStatus
+		if err := c.doJSON(ctx, req, opts, &next); err != nil {
+			return status.Warm, err
+		}
+		status = next
+	}
+	return status.Warm, nil
+}
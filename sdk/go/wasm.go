@@ -0,0 +1,512 @@
+package wasmify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// wasmMagic is the preamble every WebAssembly binary starts with: the
+// "\0asm" magic number followed by binary format version 1
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// ErrNotWasm is matched by the error returned when a file given for upload
+// does not start with the WebAssembly preamble
+var ErrNotWasm = errors.New("not a WebAssembly module")
+
+// checkWasmHeader reads only the preamble of the file at path, so obvious
+// mistakes such as uploading the wrong file fail before any transfer
+func checkWasmHeader(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, len(wasmMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	switch {
+	case n < 4 || !bytes.Equal(header[:4], wasmMagic[:4]):
+		return fmt.Errorf("%w: %s lacks the \\0asm magic number", ErrNotWasm, path)
+	case n < len(wasmMagic):
+		return fmt.Errorf("%w: %s ends before its binary format version", ErrNotWasm, path)
+	case !bytes.Equal(header[4:], wasmMagic[4:]):
+		return fmt.Errorf("%w: %s has unsupported binary format version % x", ErrNotWasm, path, header[4:])
+	}
+	return nil
+}
+
+// Section IDs defined by the WebAssembly binary format
+const (
+	sectionCustom   = 0
+	sectionType     = 1
+	sectionImport   = 2
+	sectionFunction = 3
+	sectionTable    = 4
+	sectionMemory   = 5
+	sectionGlobal   = 6
+	sectionExport   = 7
+	sectionStart    = 8
+	sectionElement  = 9
+	sectionCode     = 10
+	sectionData     = 11
+	sectionDataCnt  = 12
+)
+
+// External kinds used by imports and exports
+const (
+	externFunc   = 0
+	externTable  = 1
+	externMemory = 2
+	externGlobal = 3
+	externTag    = 4
+)
+
+var errMalformedWasm = errors.New("malformed WebAssembly binary")
+
+// wasmSection records the location of one section in the binary
+type wasmSection struct {
+	id   byte
+	name string // only set for custom sections
+	// size is the full size of the section including its header
+	size int64
+}
+
+type wasmFuncType struct {
+	params  []string
+	results []string
+}
+
+type wasmLimits struct {
+	min    uint64
+	max    uint64
+	hasMax bool
+}
+
+type wasmImport struct {
+	module string
+	name   string
+	kind   byte
+	// typeIndex is set for function imports
+	typeIndex uint32
+	// limits is set for memory imports
+	limits wasmLimits
+}
+
+type wasmExport struct {
+	name  string
+	kind  byte
+	index uint32
+}
+
+// wasmBinary is the subset of a parsed WebAssembly binary the SDK inspects
+type wasmBinary struct {
+	size     int64
+	sections []wasmSection
+	types    []wasmFuncType
+	imports  []wasmImport
+	// funcs holds the type index of every function defined by the module
+	funcs     []uint32
+	memories  []wasmLimits
+	exports   []wasmExport
+	dataSizes []int64
+}
+
+// funcType returns the signature of the function at index in the module's
+// function index space, where imported functions come first
+func (b *wasmBinary) funcType(index uint32) (wasmFuncType, bool) {
+	for _, imp := range b.imports {
+		if imp.kind != externFunc {
+			continue
+		}
+		if index == 0 {
+			return b.typeAt(imp.typeIndex)
+		}
+		index--
+	}
+	if int(index) >= len(b.funcs) {
+		return wasmFuncType{}, false
+	}
+	return b.typeAt(b.funcs[index])
+}
+
+func (b *wasmBinary) typeAt(index uint32) (wasmFuncType, bool) {
+	if int(index) >= len(b.types) {
+		return wasmFuncType{}, false
+	}
+	return b.types[index], true
+}
+
+// hasMemory reports whether the module defines or imports a linear memory
+func (b *wasmBinary) hasMemory() bool {
+	if len(b.memories) > 0 {
+		return true
+	}
+	for _, imp := range b.imports {
+		if imp.kind == externMemory {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWasmFile reads and parses the WebAssembly binary at path
+func parseWasmFile(path string) (*wasmBinary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return parseWasm(data)
+}
+
+// parseWasm parses the sections of a WebAssembly binary that the SDK cares
+// about; other sections are only recorded by size
+func parseWasm(data []byte) (*wasmBinary, error) {
+	if len(data) < len(wasmMagic) || !bytes.Equal(data[:len(wasmMagic)], wasmMagic) {
+		return nil, fmt.Errorf("%w: missing magic number or unsupported version", errMalformedWasm)
+	}
+
+	bin := &wasmBinary{size: int64(len(data))}
+	r := &wasmReader{data: data, pos: len(wasmMagic)}
+	for !r.done() {
+		start := r.pos
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.bytes(int(n))
+		if err != nil {
+			return nil, err
+		}
+
+		section := wasmSection{id: id, size: int64(r.pos - start)}
+		sr := &wasmReader{data: payload}
+		switch id {
+		case sectionCustom:
+			section.name, err = sr.name()
+		case sectionType:
+			err = bin.parseTypes(sr)
+		case sectionImport:
+			err = bin.parseImports(sr)
+		case sectionFunction:
+			bin.funcs, err = sr.u32Vec()
+		case sectionMemory:
+			err = bin.parseMemories(sr)
+		case sectionExport:
+			err = bin.parseExports(sr)
+		case sectionData:
+			err = bin.parseData(sr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", id, err)
+		}
+		bin.sections = append(bin.sections, section)
+	}
+
+	return bin, nil
+}
+
+func (b *wasmBinary) parseTypes(r *wasmReader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		form, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if form != 0x60 {
+			return fmt.Errorf("%w: unsupported type form 0x%x", errMalformedWasm, form)
+		}
+		var ft wasmFuncType
+		if ft.params, err = r.valueTypes(); err != nil {
+			return err
+		}
+		if ft.results, err = r.valueTypes(); err != nil {
+			return err
+		}
+		b.types = append(b.types, ft)
+	}
+	return nil
+}
+
+func (b *wasmBinary) parseImports(r *wasmReader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		var imp wasmImport
+		if imp.module, err = r.name(); err != nil {
+			return err
+		}
+		if imp.name, err = r.name(); err != nil {
+			return err
+		}
+		if imp.kind, err = r.byte(); err != nil {
+			return err
+		}
+		switch imp.kind {
+		case externFunc:
+			imp.typeIndex, err = r.u32()
+		case externTable:
+			if _, err = r.byte(); err == nil {
+				_, err = r.limits()
+			}
+		case externMemory:
+			imp.limits, err = r.limits()
+		case externGlobal:
+			_, err = r.bytes(2) // value type and mutability
+		case externTag:
+			if _, err = r.byte(); err == nil {
+				imp.typeIndex, err = r.u32()
+			}
+		default:
+			err = fmt.Errorf("%w: unknown import kind %d", errMalformedWasm, imp.kind)
+		}
+		if err != nil {
+			return err
+		}
+		b.imports = append(b.imports, imp)
+	}
+	return nil
+}
+
+func (b *wasmBinary) parseMemories(r *wasmReader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		l, err := r.limits()
+		if err != nil {
+			return err
+		}
+		b.memories = append(b.memories, l)
+	}
+	return nil
+}
+
+func (b *wasmBinary) parseExports(r *wasmReader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		var exp wasmExport
+		if exp.name, err = r.name(); err != nil {
+			return err
+		}
+		if exp.kind, err = r.byte(); err != nil {
+			return err
+		}
+		if exp.index, err = r.u32(); err != nil {
+			return err
+		}
+		b.exports = append(b.exports, exp)
+	}
+	return nil
+}
+
+func (b *wasmBinary) parseData(r *wasmReader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		flags, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if flags == 2 {
+			if _, err := r.u32(); err != nil { // memory index
+				return err
+			}
+		}
+		if flags != 1 {
+			if err := r.skipConstExpr(); err != nil {
+				return err
+			}
+		}
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if _, err := r.bytes(int(size)); err != nil {
+			return err
+		}
+		b.dataSizes = append(b.dataSizes, int64(size))
+	}
+	return nil
+}
+
+// wasmReader decodes the primitive encodings of the WebAssembly binary
+// format
+type wasmReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *wasmReader) done() bool { return r.pos >= len(r.data) }
+
+func (r *wasmReader) byte() (byte, error) {
+	if r.done() {
+		return 0, fmt.Errorf("%w: unexpected end of data", errMalformedWasm)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *wasmReader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.pos {
+		return nil, fmt.Errorf("%w: unexpected end of data", errMalformedWasm)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// uleb decodes an unsigned LEB128 integer of at most bits bits
+func (r *wasmReader) uleb(bits uint) (uint64, error) {
+	var result uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= bits+7 {
+			return 0, fmt.Errorf("%w: integer too long", errMalformedWasm)
+		}
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+}
+
+func (r *wasmReader) u32() (uint32, error) {
+	v, err := r.uleb(32)
+	return uint32(v), err
+}
+
+// sleb skips a signed LEB128 integer; its value is never needed
+func (r *wasmReader) sleb(bits uint) error {
+	_, err := r.uleb(bits)
+	return err
+}
+
+func (r *wasmReader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(int(n))
+	return string(b), err
+}
+
+func (r *wasmReader) u32Vec() ([]uint32, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint32, 0, n)
+	for i := uint32(0); i < n; i++ {
+		v, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (r *wasmReader) limits() (wasmLimits, error) {
+	flags, err := r.byte()
+	if err != nil {
+		return wasmLimits{}, err
+	}
+	var l wasmLimits
+	if l.min, err = r.uleb(64); err != nil {
+		return l, err
+	}
+	if flags&0x01 != 0 {
+		l.hasMax = true
+		if l.max, err = r.uleb(64); err != nil {
+			return l, err
+		}
+	}
+	return l, nil
+}
+
+func (r *wasmReader) valueTypes() ([]string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, n)
+	for i := uint32(0); i < n; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, valueTypeName(b))
+	}
+	return out, nil
+}
+
+// skipConstExpr skips a constant expression such as a data segment offset
+func (r *wasmReader) skipConstExpr() error {
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case 0x0b: // end
+			return nil
+		case 0x41: // i32.const
+			err = r.sleb(32)
+		case 0x42: // i64.const
+			err = r.sleb(64)
+		case 0x23: // global.get
+			_, err = r.u32()
+		default:
+			err = fmt.Errorf("%w: unsupported constant expression opcode 0x%x", errMalformedWasm, op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// valueTypeName returns the text-format name of a value type
+func valueTypeName(b byte) string {
+	switch b {
+	case 0x7f:
+		return "i32"
+	case 0x7e:
+		return "i64"
+	case 0x7d:
+		return "f32"
+	case 0x7c:
+		return "f64"
+	case 0x7b:
+		return "v128"
+	case 0x70:
+		return "funcref"
+	case 0x6f:
+		return "externref"
+	}
+	return fmt.Sprintf("0x%x", b)
+}
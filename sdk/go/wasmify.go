@@ -1,16 +1,22 @@
 package wasmify
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
 )
 
 // WasmModule represents a WebAssembly module
@@ -20,6 +26,76 @@ type WasmModule struct {
 	Version  string                 `json:"version"`
 	FilePath string                 `json:"filePath"`
 	Metadata map[string]interface{} `json:"metadata"`
+	// Hash is the hex-encoded SHA-256 digest of the module content, when
+	// the server reports it
+	Hash string `json:"hash,omitempty"`
+	// MaxConcurrency is the number of executions the module allows at
+	// once; zero means no limit is advertised
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+	// RateLimit caps invocations of the module per second; zero means
+	// no limit is set
+	RateLimit int `json:"rateLimit,omitempty"`
+	// DefaultFunction is called by calls that name no function, and
+	// DefaultConfig fills in the config of every call; see
+	// UploadOptions.DefaultFunction
+	DefaultFunction string          `json:"defaultFunction,omitempty"`
+	DefaultConfig   ExecutionConfig `json:"-"`
+}
+
+// moduleRecord is the module shape returned by the modules endpoints
+type moduleRecord struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	WasmFile       string `json:"wasmFile"`
+	Description    string `json:"description"`
+	Language       string `json:"language"`
+	Size           int64  `json:"size"`
+	Hash           string `json:"hash"`
+	IsPublic       bool   `json:"isPublic"`
+	CreatedAt      string `json:"createdAt"`
+	UpdatedAt      string `json:"updatedAt"`
+	MaxConcurrency int    `json:"maxConcurrency"`
+	RateLimit      int    `json:"rateLimit"`
+
+	DefaultFunction string             `json:"defaultFunction"`
+	DefaultConfig   *executionDefaults `json:"defaultConfig"`
+
+	// Metadata is the caller-defined metadata of the module
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// toModule converts the record. Caller-defined metadata is merged into
+// WasmModule.Metadata, where the server's own fields win on a clash.
+func (m *moduleRecord) toModule() *WasmModule {
+	metadata := make(map[string]interface{}, len(m.Metadata)+7)
+	for k, v := range m.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range map[string]interface{}{
+		"description": m.Description,
+		"language":    m.Language,
+		"size":        m.Size,
+		"hash":        m.Hash,
+		"isPublic":    m.IsPublic,
+		"createdAt":   m.CreatedAt,
+		"updatedAt":   m.UpdatedAt,
+	} {
+		metadata[k] = v
+	}
+
+	return &WasmModule{
+		ID:              m.ID,
+		Name:            m.Name,
+		Version:         m.Version,
+		FilePath:        m.WasmFile,
+		Metadata:        metadata,
+		Hash:            m.Hash,
+		MaxConcurrency:  m.MaxConcurrency,
+		RateLimit:       m.RateLimit,
+		DefaultFunction: m.DefaultFunction,
+		DefaultConfig:   m.DefaultConfig.config(),
+	}
 }
 
 // ExecutionResult represents the result of WebAssembly execution
@@ -29,19 +105,400 @@ type ExecutionResult struct {
 	ExecutionTime float64     `json:"executionTime"`
 	MemoryUsed    int64       `json:"memoryUsed"`
 	Error         string      `json:"error,omitempty"`
+	// QueueTime is how long, in milliseconds, the call waited to be
+	// scheduled before it started executing
+	QueueTime float64 `json:"queueTime,omitempty"`
+	// ColdStart reports whether the module had to be instantiated for
+	// this call, and ColdStartTime how long that took in milliseconds.
+	// ExecutionTime covers only the function itself. Both are zero when
+	// the call ran on a warm instance or the server does not report them.
+	ColdStart     bool    `json:"coldStart,omitempty"`
+	ColdStartTime float64 `json:"coldStartTime,omitempty"`
+	// SnapshotUsed reports whether the call started from the snapshot
+	// named by ExecutionConfig.SnapshotID
+	SnapshotUsed bool `json:"snapshotUsed,omitempty"`
+	// CorrelationID is the ID the request was traced under, as echoed by
+	// the server or else as sent; see WithCorrelationID
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Local reports whether the call ran in-process through ExecuteLocal
+	// rather than on the server
+	Local bool `json:"local,omitempty"`
+	// Warnings lists non-fatal issues the runtime reported, such as use of
+	// a deprecated import or memory nearing its limit. It is nil when there
+	// are none.
+	Warnings []string `json:"warnings,omitempty"`
+	// Metadata carries side-band details the server attached to the
+	// execution, such as a trace ID, a logs reference or the cache status.
+	// Keys depend on the deployment; it is nil when there are none.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// JobID and Status are set for executions submitted with
+	// ExecuteModuleAsync; Status stays ExecutionQueued or ExecutionRunning
+	// until the job has finished
+	JobID  string `json:"jobId,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// Priority is a scheduling hint for executions on a shared backend
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+// priorityHeader carries the execution priority so gateways can schedule
+// without parsing the body
+const priorityHeader = "X-Wasmify-Priority"
+
+// Runtime defaults used when neither the call nor
+// Config.DefaultExecutionConfig sets a value
+const (
+	defaultMemoryMin        = 64
+	defaultMemoryMax        = 512
+	defaultMaxExecutionTime = 30 * time.Second
+)
+
+// ExecutionConfig controls how the runtime executes a module function. Zero
+// fields fall back to Config.DefaultExecutionConfig, then to the runtime
+// defaults.
+type ExecutionConfig struct {
+	// MemoryMin and MemoryMax bound linear memory in 64KiB pages
+	// (default 64 and 512)
+	MemoryMin int
+	MemoryMax int
+	// MemoryLimitBytes expresses the memory cap in bytes instead. It is
+	// rounded down to whole pages and wins over a larger MemoryMax.
+	MemoryLimitBytes int64
+	// MemoryGrowLimit caps the number of pages memory.grow may add at
+	// runtime, whatever the maximum; zero leaves growth up to the maximum.
+	// Executions over either limit fail with ErrMemoryLimitExceeded.
+	MemoryGrowLimit int
+	// MaxExecutionTime aborts the call once exceeded (default 30s)
+	MaxExecutionTime time.Duration
+	// EnableWasi exposes WASI imports to the module (default true)
+	EnableWasi *bool
+	// RandomSeed seeds the runtime PRNG behind WASI random_get so repeated
+	// calls with the same seed observe the same bytes. Clock imports are
+	// not affected, so modules that derive randomness from the time stay
+	// nondeterministic. When nil the runtime uses real entropy.
+	RandomSeed *uint64
+	// InputContentType and OutputContentType select the wire format of
+	// args and results (JSON by default). Formats other than JSON need a
+	// codec; msgpack is built in and RegisterCodec adds others.
+	InputContentType  string
+	OutputContentType string
+	// Priority asks the server to schedule the call ahead of or behind
+	// other work; empty leaves the server default, normally
+	// PriorityNormal
+	Priority Priority
+	// SnapshotID restores the module from a snapshot made by
+	// CreateSnapshot instead of instantiating it from scratch
+	SnapshotID string
+	// RetryOnErrorPatterns lists regular expressions matched against the
+	// Error of a result. A match marks a transient failure inside the
+	// module, such as a dropped database connection, and the execution is
+	// retried up to MaxErrorRetries times (default 3) with the client's
+	// retry backoff. This is separate from retries of failed HTTP requests.
+	RetryOnErrorPatterns []string
+	MaxErrorRetries      int
+	// Extra holds additional runtime settings sent verbatim; they take
+	// precedence over the typed fields
+	Extra map[string]interface{}
+}
+
+// defaultMaxErrorRetries caps retries triggered by RetryOnErrorPatterns
+const defaultMaxErrorRetries = 3
+
+func (c ExecutionConfig) maxErrorRetries() int {
+	if c.MaxErrorRetries > 0 {
+		return c.MaxErrorRetries
+	}
+	return defaultMaxErrorRetries
+}
+
+// withDefaults fills the zero fields of c from d. Extra settings from both
+// are kept, with those in c winning.
+func (c ExecutionConfig) withDefaults(d ExecutionConfig) ExecutionConfig {
+	if c.MemoryMin == 0 {
+		c.MemoryMin = d.MemoryMin
+	}
+	if c.MemoryMax == 0 {
+		c.MemoryMax = d.MemoryMax
+	}
+	if c.MemoryLimitBytes == 0 {
+		c.MemoryLimitBytes = d.MemoryLimitBytes
+	}
+	if c.MemoryGrowLimit == 0 {
+		c.MemoryGrowLimit = d.MemoryGrowLimit
+	}
+	if c.MaxExecutionTime == 0 {
+		c.MaxExecutionTime = d.MaxExecutionTime
+	}
+	if c.EnableWasi == nil {
+		c.EnableWasi = d.EnableWasi
+	}
+	if c.RandomSeed == nil {
+		c.RandomSeed = d.RandomSeed
+	}
+	if c.InputContentType == "" {
+		c.InputContentType = d.InputContentType
+	}
+	if c.OutputContentType == "" {
+		c.OutputContentType = d.OutputContentType
+	}
+	if c.Priority == "" {
+		c.Priority = d.Priority
+	}
+	if c.SnapshotID == "" {
+		c.SnapshotID = d.SnapshotID
+	}
+	if c.RetryOnErrorPatterns == nil {
+		c.RetryOnErrorPatterns = d.RetryOnErrorPatterns
+	}
+	if c.MaxErrorRetries == 0 {
+		c.MaxErrorRetries = d.MaxErrorRetries
+	}
+	if len(d.Extra) > 0 {
+		extra := make(map[string]interface{}, len(d.Extra)+len(c.Extra))
+		for k, v := range d.Extra {
+			extra[k] = v
+		}
+		for k, v := range c.Extra {
+			extra[k] = v
+		}
+		c.Extra = extra
+	}
+	return c
+}
+
+// wire converts c into the config object sent with execution requests
+func (c ExecutionConfig) wire() map[string]interface{} {
+	// validated by newExecutionRequestData
+	memMin, memMax, _ := c.memoryPages()
+	maxTime := defaultMaxExecutionTime.Milliseconds()
+	if c.MaxExecutionTime > 0 {
+		maxTime = c.MaxExecutionTime.Milliseconds()
+	}
+	enableWasi := true
+	if c.EnableWasi != nil {
+		enableWasi = *c.EnableWasi
+	}
+
+	config := map[string]interface{}{
+		"memory":           map[string]int{"min": memMin, "max": memMax},
+		"maxExecutionTime": maxTime,
+		"enableWasi":       enableWasi,
+	}
+	if c.MemoryGrowLimit > 0 {
+		config["memoryGrowLimit"] = c.MemoryGrowLimit
+	}
+	if c.SnapshotID != "" {
+		config["snapshotId"] = c.SnapshotID
+	}
+	if c.RandomSeed != nil {
+		// Sent as a string because JSON numbers lose precision above 2^53
+		config["randomSeed"] = strconv.FormatUint(*c.RandomSeed, 10)
+	}
+
+	// Merge user config
+	for k, v := range c.Extra {
+		config[k] = v
+	}
+
+	return config
 }
 
 // Config represents client configuration
 type Config struct {
-	APIURL  string
-	APIKey  string
+	APIURL string
+	APIKey string
+	// APIKeyFunc, when set, is called for the key of every request, e.g.
+	// to read a rotating key from a secret store; APIKey is used when it
+	// returns "". A request rejected with 401 is sent once more with a
+	// freshly fetched key before failing.
+	APIKeyFunc func() (string, error)
+	// TokenSource, when set, supplies an OAuth2 token for every request
+	// in place of APIKey and APIKeyFunc. Tokens are reused until they
+	// expire.
+	TokenSource oauth2.TokenSource
+	// Timeout bounds each attempt of a request, including reading the
+	// response. A deadline on the call's context also applies and the
+	// earlier of the two wins, so Timeout never extends it. Long-lived
+	// streams such as StreamBuildLogs are bounded by the context alone.
+	// Zero means 30 seconds; a negative Timeout disables the per-attempt
+	// timeout, leaving only the context to bound requests.
 	Timeout time.Duration
+
+	// MaxRetries is the number of times a request that failed with a
+	// transient error is retried. Zero disables retries. Request bodies
+	// are buffered or reopened for every attempt, so POSTs such as
+	// executions are replayed intact.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles, with
+	// jitter, on each subsequent attempt. A Retry-After header on the
+	// failed response is honoured instead, up to one minute.
+	RetryBackoff time.Duration
+	// RetryableStatus decides which response statuses are retried; nil
+	// retries 429, 502, 503 and 504
+	RetryableStatus func(code int) bool
+	// RetryBufferLimit is the size up to which a streamed request body
+	// that cannot be rewound, such as a BuildModule archive read from a
+	// pipe, is held in memory so the request can be retried. Seekable
+	// bodies are rewound instead. Larger bodies are sent once without
+	// retries. Zero disables buffering.
+	RetryBufferLimit int64
+
+	// Debug dumps every request and response, with credentials redacted,
+	// to DebugWriter (os.Stderr by default). Setting WASMIFY_DEBUG=1 in
+	// the environment has the same effect.
+	Debug       bool
+	DebugWriter io.Writer
+
+	// ReadCoalesceWindow collapses identical ListModules and GetModule
+	// calls made within this window into a single request, which helps
+	// chatty pollers. It should stay well under a second; zero disables it.
+	// Collapsed calls share the retry policy of the first, but calls with
+	// different correlation IDs are never collapsed.
+	ReadCoalesceWindow time.Duration
+
+	// ContextLabels maps label names to context keys. For every request the
+	// client reads these keys (and only these) from the request context and
+	// attaches the values found to debug dumps and RequestEvents, so SDK
+	// activity can be correlated with the caller's own request or tenant.
+	ContextLabels map[string]interface{}
+	// Logger, when set, is told about every HTTP request and response.
+	// NewSlogLogger adapts a *slog.Logger.
+	Logger Logger
+	// OnRequest, when set, is called after every HTTP round trip
+	OnRequest func(RequestEvent)
+
+	// Policy, when set, is checked before every upload; modules that
+	// violate it are rejected without contacting the server
+	Policy *Policy
+	// MetadataSchema, when set, is checked against the metadata given to
+	// uploads and UpdateModuleMetadata; nonconforming metadata is rejected
+	// with a *MetadataError without contacting the server
+	MetadataSchema *MetadataSchema
+
+	// Codec serializes request and response envelopes, JSONCodec by
+	// default. A non-JSON codec sees generic maps, slices and scalars
+	// named after the SDK's json field names. Execution args and results
+	// keep their own per-call content types.
+	Codec Codec
+
+	// HTTPClient, when set, sends every request instead of a client built
+	// by the SDK, e.g. for mTLS or a tuned transport. It is copied, not
+	// modified; debug dumps wrap its transport in the copy. TCPKeepAlive
+	// does not apply to it, and its own Timeout, if any, also bounds
+	// streams such as StreamBuildLogs, so it is best left zero in favour
+	// of Config.Timeout.
+	HTTPClient *http.Client
+
+	// TCPKeepAlive sets the TCP keepalive period of API connections, so
+	// NATs and load balancers do not silently drop them; zero keeps the
+	// Go default and a negative value disables keepalive probes.
+	TCPKeepAlive time.Duration
+	// KeepAlivePing makes the client send a lightweight request to the
+	// API whenever it has been idle for KeepAliveInterval (default 30s),
+	// keeping a connection warm for long-running daemons. Pings stop when
+	// the client is shut down or closed.
+	KeepAlivePing     bool
+	KeepAliveInterval time.Duration
+
+	// DefaultRegions are deployed to when Deploy or DeployToEdge is given
+	// no regions; empty deploys globally
+	DefaultRegions []string
+
+	// MaxUploadBytesPerSec and MaxDownloadBytesPerSec cap the transfer
+	// rate of module uploads, build sources and downloads, shared by all
+	// concurrent transfers of the client; zero means unlimited. A paced
+	// transfer can take far longer than Timeout, so it is bounded only by
+	// the call's context.
+	MaxUploadBytesPerSec   int64
+	MaxDownloadBytesPerSec int64
+
+	// RequestsPerSecond, when positive, spaces out requests, retries
+	// included, to stay under a server quota; calls wait their turn until
+	// their context is done
+	RequestsPerSecond float64
+
+	// DefaultExecutionConfig supplies execution settings for every call
+	// that leaves them unset. Its own zero fields keep the runtime
+	// defaults of 64-512 memory pages, 30s and WASI enabled.
+	DefaultExecutionConfig ExecutionConfig
 }
 
+// UploadOptions holds optional settings for an upload
+type UploadOptions struct {
+	// Metadata is attached to the module on the server
+	Metadata map[string]string
+	// PrecomputedSHA256 is the hex-encoded SHA-256 of the file, for
+	// callers that already have it. It is sent with the upload, keys
+	// deduplication of concurrent uploads and is checked against the
+	// hash the server reports.
+	PrecomputedSHA256 string
+	// SkipValidation turns off the local checks made before and during
+	// the upload: that the file starts with the WebAssembly preamble,
+	// failing with ErrNotWasm otherwise, and that PrecomputedSHA256 matches
+	// the content as it streams, failing with ErrDigestMismatch otherwise.
+	SkipValidation bool
+	// VerifyUpload requires proof that the server stored what was sent.
+	// The hash of the stream is always checked against the one the upload
+	// response reports; with VerifyUpload, a response without one is
+	// checked against the stored module instead, and the upload fails if
+	// the server reports no hash at all.
+	VerifyUpload bool
+	// DefaultFunction names the function executed when a call names none,
+	// as Run does, and DefaultConfig the config every call of the module
+	// starts from. Fields set per call still override DefaultConfig, which
+	// in turn overrides Config.DefaultExecutionConfig.
+	DefaultFunction string
+	DefaultConfig   ExecutionConfig
+	// Progress, if set, is called as the file is sent and once more with
+	// the final total when all of it has been sent. A retried upload
+	// reports from zero again.
+	Progress ProgressFunc
+}
+
+// ProgressFunc receives the number of bytes sent so far out of the total
+type ProgressFunc func(bytesSent, totalBytes int64)
+
 // Client represents the Wasmify Go client
 type Client struct {
-	config    Config
+	config     Config
 	httpClient *http.Client
+	retry      RetryPolicy
+	codec      Codec
+	// accept is the content type asked of the server for a non-JSON codec
+	accept string
+
+	// uploads collapses concurrent uploads of the same file
+	uploads flightGroup
+
+	// reads collapses bursts of identical reads
+	reads *readCoalescer
+
+	permissions  permissionCache
+	capabilities capabilityCache
+	// defaults caches moduleDefaultsOf by module ID
+	defaults sync.Map
+
+	// uploadLimit and downloadLimit are nil when transfers are unlimited
+	uploadLimit   *bandwidthLimiter
+	downloadLimit *bandwidthLimiter
+	// limiter is nil without Config.RequestsPerSecond
+	limiter *rate.Limiter
+	// tokens caches Config.TokenSource
+	tokens oauth2.TokenSource
+
+	mu    sync.Mutex
+	state lifecycle
+
+	// skew is the last measured server clock offset, in nanoseconds
+	skew atomic.Int64
+	// lastRequest is when the last attempt started, in Unix nanoseconds
+	lastRequest atomic.Int64
 }
 
 // NewClient creates a new Wasmify client
@@ -49,13 +506,68 @@ func NewClient(config Config) *Client {
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
-	
-	return &Client{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
+
+	c := &Client{
+		config:     config,
+		httpClient: newHTTPClient(config),
+		retry: RetryPolicy{
+			MaxRetries:      config.MaxRetries,
+			Backoff:         config.RetryBackoff,
+			RetryableStatus: config.RetryableStatus,
 		},
+		codec:         config.Codec,
+		reads:         newReadCoalescer(config.ReadCoalesceWindow),
+		uploadLimit:   newBandwidthLimiter(config.MaxUploadBytesPerSec),
+		downloadLimit: newBandwidthLimiter(config.MaxDownloadBytesPerSec),
+		state: lifecycle{
+			closing: make(chan struct{}),
+			abort:   make(chan struct{}),
+		},
+	}
+	if config.TokenSource != nil {
+		c.tokens = oauth2.ReuseTokenSource(nil, config.TokenSource)
+	}
+	if config.RequestsPerSecond > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(config.RequestsPerSecond), 1)
 	}
+	if c.codec == nil {
+		c.codec = JSONCodec{}
+	}
+	if !isJSONCodec(c.codec) {
+		// The codec interface only reports its content type when encoding
+		_, c.accept, _ = c.codec.Encode(nil)
+	}
+
+	if debugEnabled(config) {
+		c.httpClient.Transport = newDebugTransport(c.httpClient.Transport, config.DebugWriter, c.contextLabels)
+	}
+
+	if config.KeepAlivePing {
+		interval := config.KeepAliveInterval
+		if interval <= 0 {
+			interval = DefaultKeepAliveInterval
+		}
+		go c.keepAlive(interval)
+	}
+
+	return c
+}
+
+// newHTTPClient returns a copy of config.HTTPClient, or a new client when
+// none is given, with the SDK's redirect policy unless it has its own
+func newHTTPClient(config Config) *http.Client {
+	if config.HTTPClient == nil {
+		return &http.Client{
+			Transport:     newTransport(config),
+			CheckRedirect: checkRedirect,
+		}
+	}
+
+	hc := *config.HTTPClient
+	if hc.CheckRedirect == nil {
+		hc.CheckRedirect = checkRedirect
+	}
+	return &hc
 }
 
 // NewDefaultClient creates a client with default configuration
@@ -65,312 +577,440 @@ func NewDefaultClient() *Client {
 	})
 }
 
-// UploadModule uploads a WebAssembly module to Wasmify
-func (c *Client) UploadModule(filePath, name, version string) (*WasmModule, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
+// UploadModule uploads a WebAssembly module to Wasmify. The file is
+// streamed and hashed as it is sent; the digest is returned in Hash and
+// checked against the one the server reports. Concurrent uploads of the
+// same unmodified file under the same name and version share a single
+// request, and every caller receives its result; the options of the call
+// that started the request apply. A caller whose context ends stops
+// waiting without failing the others, and the request is cancelled only
+// once every caller sharing it has gone.
+func (c *Client) UploadModule(filePath, name, version string, opts ...CallOption) (*WasmModule, error) {
+	return c.UploadModuleContext(context.Background(), filePath, name, version, opts...)
+}
 
-	// Create multipart form
-	var requestBody bytes.Buffer
-	writer := multipart.NewWriter(&requestBody)
+// UploadModuleContext is like UploadModule but stops when ctx is done
+func (c *Client) UploadModuleContext(ctx context.Context, filePath, name, version string, opts ...CallOption) (*WasmModule, error) {
+	return c.UploadModuleWithOptions(ctx, filePath, name, version, UploadOptions{}, opts...)
+}
 
-	// Add file
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
+// UploadModuleWithOptions is like UploadModule but accepts upload options.
+// If the client has a Policy, the module is validated against it first and
+// a *PolicyError is returned on violation. Likewise options.Metadata must
+// satisfy the client's MetadataSchema, if any.
+func (c *Client) UploadModuleWithOptions(ctx context.Context, filePath, name, version string, options UploadOptions, opts ...CallOption) (*WasmModule, error) {
+	if !options.SkipValidation {
+		if err := checkWasmHeader(filePath); err != nil {
+			return nil, err
+		}
 	}
-	
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to copy file: %w", err)
+	if c.config.Policy != nil {
+		if err := c.config.Policy.Validate(filePath, options); err != nil {
+			return nil, err
+		}
+	}
+	if c.config.MetadataSchema != nil {
+		if err := c.config.MetadataSchema.validateUpload(options.Metadata); err != nil {
+			return nil, err
+		}
 	}
 
-	// Add form fields
-	_ = writer.WriteField("name", name)
-	_ = writer.WriteField("version", version)
-	
-	err = writer.Close()
-	if err != nil {
-		return nil, fmt.Errorf("failed to close writer: %w", err)
+	var id string
+	if options.PrecomputedSHA256 != "" {
+		digest, _, err := parsePin(PinModule(options.PrecomputedSHA256))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PrecomputedSHA256: %w", err)
+		}
+		// identical content under another path can share the upload too
+		id = digest
+	} else {
+		var err error
+		if id, err = fileIdentity(filePath); err != nil {
+			return nil, err
+		}
 	}
 
-	// Create request
-	req, err := http.NewRequest("POST", c.config.APIURL+"/upload", &requestBody)
+	metadata, err := json.Marshal(options.Metadata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	defaults, err := encodeExecutionDefaults(options.DefaultConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	if c.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	key := strings.Join([]string{id, name, version, string(metadata), options.DefaultFunction, defaults, strconv.FormatBool(options.VerifyUpload)}, "\x00")
+	v, err := c.uploads.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return c.uploadModule(ctx, filePath, name, version, options, opts)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Send request
-	resp, err := c.httpClient.Do(req)
+	module := *v.(*WasmModule)
+	module.FilePath = filePath
+	return &module, nil
+}
+
+func (c *Client) uploadModule(ctx context.Context, filePath, name, version string, options UploadOptions, opts []CallOption) (*WasmModule, error) {
+	body, err := newUploadBody(filePath, name, version, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("upload failed with status: %s", resp.Status)
+	req := &request{
+		op:          "upload",
+		method:      http.MethodPost,
+		path:        "/upload",
+		stream:      body.open,
+		contentType: body.contentType(),
 	}
 
-	// Parse response
-	var result struct {
-		Success bool `json:"success"`
-		Data    struct {
-			Key     string                 `json:"key"`
-			ETag    string                 `json:"etag"`
-			Size    int64                  `json:"size"`
-			Headers map[string]interface{} `json:"headers"`
-		} `json:"data"`
+	var data struct {
+		Key     string                 `json:"key"`
+		ETag    string                 `json:"etag"`
+		Size    int64                  `json:"size"`
+		Hash    string                 `json:"hash"`
+		Headers map[string]interface{} `json:"headers"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	sum, err := body.sum()
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, err
 	}
-
-	if !result.Success {
-		return nil, fmt.Errorf("upload failed")
+	stored := data.Hash
+	if stored == "" && options.VerifyUpload {
+		module, err := c.GetModuleContext(ctx, data.Key, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify upload: %w", err)
+		}
+		if module.Hash == "" {
+			return nil, fmt.Errorf("failed to verify upload: server reports no hash for module %s", data.Key)
+		}
+		stored = module.Hash
+	}
+	if stored != "" && normalizeDigest(stored) != sum {
+		return nil, fmt.Errorf("%w: uploaded sha256:%s but server stored %q", ErrDigestMismatch, sum, stored)
 	}
 
 	return &WasmModule{
-		ID:       result.Data.Key,
+		ID:       data.Key,
 		Name:     name,
 		Version:  version,
 		FilePath: filePath,
+		Hash:     sum,
 		Metadata: map[string]interface{}{
-			"etag":    result.Data.ETag,
-			"size":    result.Data.Size,
-			"headers": result.Data.Headers,
+			"etag":    data.ETag,
+			"size":    data.Size,
+			"headers": data.Headers,
 		},
 	}, nil
 }
 
-// ExecuteModule executes a WebAssembly module function
-func (c *Client) ExecuteModule(moduleID, functionName string, args []interface{}, config map[string]interface{}) (*ExecutionResult, error) {
-	requestData := map[string]interface{}{
-		"moduleId":     moduleID,
-		"functionName": functionName,
-		"args":         args,
-		"config": map[string]interface{}{
-			"memory":          map[string]int{"min": 64, "max": 512},
-			"maxExecutionTime": 30000,
-			"enableWasi":      true,
-		},
+// fileIdentity identifies the file at path and its current revision
+// without reading it, so uploads can be deduplicated up front
+func fileIdentity(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
 	}
-
-	// Merge user config
-	for k, v := range config {
-		requestData["config"].(map[string]interface{})[k] = v
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
 	}
+	return fmt.Sprintf("%s\x00%d\x00%d", abs, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// ExecuteModule executes a WebAssembly module function. Entries in config
+// are sent as runtime settings and override the defaults. Nil args are
+// sent as an empty list.
+func (c *Client) ExecuteModule(moduleID, functionName string, args []interface{}, config map[string]interface{}, opts ...CallOption) (*ExecutionResult, error) {
+	return c.ExecuteModuleContext(context.Background(), moduleID, functionName, args, config, opts...)
+}
 
-	jsonData, err := json.Marshal(requestData)
+// ExecuteModuleContext is like ExecuteModule but stops when ctx is done
+func (c *Client) ExecuteModuleContext(ctx context.Context, moduleID, functionName string, args []interface{}, config map[string]interface{}, opts ...CallOption) (*ExecutionResult, error) {
+	return c.Execute(ctx, moduleID, functionName, args, ExecutionConfig{Extra: config}, opts...)
+}
+
+// Execute executes a WebAssembly module function with a typed configuration.
+// moduleID may be a pinned "sha256:<hex>" reference. An empty functionName
+// calls the module's default function, and the module's default config
+// fills the fields config leaves unset whichever function is called.
+func (c *Client) Execute(ctx context.Context, moduleID, functionName string, args []interface{}, config ExecutionConfig, opts ...CallOption) (*ExecutionResult, error) {
+	opts, _ = c.withCorrelationID(opts)
+	moduleID, functionName, config, err := c.prepareExecution(ctx, moduleID, functionName, config, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.config.APIURL+"/wasm/execute", bytes.NewBuffer(jsonData))
+	retryOn, err := compileErrorPatterns(config.RetryOnErrorPatterns)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	policy := c.newCallOptions(opts).retry
+	for attempt := 0; ; attempt++ {
+		result, err := c.execute(ctx, moduleID, functionName, args, config, opts)
+		if err != nil || attempt >= config.maxErrorRetries() || !matchesAny(retryOn, result.Error) {
+			return result, err
+		}
+		if err := sleepContext(ctx, policy.backoff(attempt)); err != nil {
+			return result, err
+		}
 	}
+}
 
-	resp, err := c.httpClient.Do(req)
+// prepareExecution resolves the module reference of a call and fills in the
+// module's default function and config, then the client defaults
+func (c *Client) prepareExecution(ctx context.Context, moduleID, functionName string, config ExecutionConfig, opts []CallOption) (string, string, ExecutionConfig, error) {
+	moduleID, err := c.resolveModuleRef(ctx, moduleID, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return "", "", config, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("execution failed with status: %s", resp.Status)
+	functionName, config, err = c.moduleDefaults(ctx, moduleID, functionName, config, opts)
+	if err != nil {
+		return "", "", config, err
 	}
+	return moduleID, functionName, config.withDefaults(c.config.DefaultExecutionConfig), nil
+}
 
-	var result struct {
-		Success bool `json:"success"`
-		Data    struct {
-			Result struct {
-				Result        interface{} `json:"result"`
-				ExecutionTime float64     `json:"executionTime"`
-				MemoryUsed    int64       `json:"memoryUsed"`
-				Error         string      `json:"error,omitempty"`
-			} `json:"result"`
-		} `json:"data"`
+// execute performs a single execution request
+func (c *Client) execute(ctx context.Context, moduleID, functionName string, args []interface{}, config ExecutionConfig, opts []CallOption) (*ExecutionResult, error) {
+	requestData, err := newExecutionRequestData(functionName, args, config)
+	if err != nil {
+		return nil, err
 	}
+	requestData["moduleId"] = moduleID
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	req, err := c.newJSONRequest("execution", http.MethodPost, "/wasm/execute", requestData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, err
 	}
-
-	if !result.Success {
-		return nil, fmt.Errorf("execution failed")
+	if config.Priority != "" {
+		req.header = http.Header{priorityHeader: {string(config.Priority)}}
 	}
-
-	return &ExecutionResult{
-		Success:       true,
-		Result:        result.Data.Result.Result,
-		ExecutionTime: result.Data.Result.ExecutionTime,
-		MemoryUsed:    result.Data.Result.MemoryUsed,
-		Error:         result.Data.Result.Error,
-	}, nil
-}
-
-// ListModules lists all available WebAssembly modules
-func (c *Client) ListModules() ([]*WasmModule, error) {
-	req, err := http.NewRequest("GET", c.config.APIURL+"/modules", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	correlationID := c.newCallOptions(opts).correlationID
+	req.onResponse = func(resp *http.Response) {
+		if id := resp.Header.Get(correlationIDHeader); id != "" {
+			correlationID = id
+		}
 	}
 
-	if c.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	var data struct {
+		Result executionPayload `json:"result"`
+	}
+	if err := c.doJSON(ctx, req, opts, &data); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	result, err := data.Result.toResult(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	result.CorrelationID = correlationID
+	return result, data.Result.limitError(result)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("list failed with status: %s", resp.Status)
+// newExecutionRequestData builds the body of a single function call
+func newExecutionRequestData(functionName string, args []interface{}, config ExecutionConfig) (map[string]interface{}, error) {
+	if _, _, err := config.memoryPages(); err != nil {
+		return nil, err
 	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Data    []struct {
-			ID          string                 `json:"id"`
-			Name        string                 `json:"name"`
-			Version     string                 `json:"version"`
-			WasmFile    string                 `json:"wasmFile"`
-			Description string                 `json:"description"`
-			Language    string                 `json:"language"`
-			Size        int64                  `json:"size"`
-			Hash        string                 `json:"hash"`
-			IsPublic    bool                   `json:"isPublic"`
-			CreatedAt   string                 `json:"createdAt"`
-			UpdatedAt   string                 `json:"updatedAt"`
-		} `json:"data"`
+	requestData := map[string]interface{}{
+		"functionName": functionName,
+		"config":       config.wire(),
+	}
+	if err := encodeArgs(requestData, args, config.InputContentType); err != nil {
+		return nil, err
+	}
+	if !isJSONContentType(config.OutputContentType) {
+		if _, err := lookupCodec(config.OutputContentType); err != nil {
+			return nil, err
+		}
+		requestData["outputContentType"] = normalizeContentType(config.OutputContentType)
 	}
+	if config.Priority != "" {
+		requestData["priority"] = config.Priority
+	}
+	return requestData, nil
+}
+
+// executionPayload is the result shape returned by the execution endpoints
+type executionPayload struct {
+	Result        interface{}            `json:"result"`
+	ExecutionTime float64                `json:"executionTime"`
+	MemoryUsed    int64                  `json:"memoryUsed"`
+	QueueTime     float64                `json:"queueTime"`
+	ColdStart     bool                   `json:"coldStart"`
+	ColdStartTime float64                `json:"coldStartTime"`
+	SnapshotUsed  bool                   `json:"snapshotUsed"`
+	Error         string                 `json:"error,omitempty"`
+	Code          string                 `json:"code,omitempty"`
+	Warnings      []string               `json:"warnings"`
+	Metadata      map[string]interface{} `json:"metadata"`
+}
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+func (p *executionPayload) toResult(config ExecutionConfig) (*ExecutionResult, error) {
+	result, err := decodeResult(p.Result, config.OutputContentType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, err
 	}
 
-	if !result.Success {
-		return nil, fmt.Errorf("list failed")
-	}
-
-	modules := make([]*WasmModule, len(result.Data))
-	for i, moduleData := range result.Data {
-		modules[i] = &WasmModule{
-			ID:       moduleData.ID,
-			Name:     moduleData.Name,
-			Version:  moduleData.Version,
-			FilePath: moduleData.WasmFile,
-			Metadata: map[string]interface{}{
-				"description": moduleData.Description,
-				"language":    moduleData.Language,
-				"size":        moduleData.Size,
-				"hash":        moduleData.Hash,
-				"isPublic":    moduleData.IsPublic,
-				"createdAt":   moduleData.CreatedAt,
-				"updatedAt":   moduleData.UpdatedAt,
-			},
-		}
+	warnings := p.Warnings
+	if len(warnings) == 0 {
+		warnings = nil
+	}
+	metadata := p.Metadata
+	if len(metadata) == 0 {
+		metadata = nil
 	}
 
-	return modules, nil
+	return &ExecutionResult{
+		Success:       true,
+		Result:        result,
+		ExecutionTime: p.ExecutionTime,
+		MemoryUsed:    p.MemoryUsed,
+		QueueTime:     p.QueueTime,
+		ColdStart:     p.ColdStart,
+		ColdStartTime: p.ColdStartTime,
+		SnapshotUsed:  p.SnapshotUsed,
+		Error:         p.Error,
+		Warnings:      warnings,
+		Metadata:      metadata,
+	}, nil
 }
 
-// DeployToEdge deploys a module to edge locations
-func (c *Client) DeployToEdge(moduleID string, regions []string) (map[string]interface{}, error) {
-	requestData := map[string]interface{}{
-		"moduleId":    moduleID,
-		"environment": "production",
-		"region":      "global",
-		"config": map[string]interface{}{
-			"memory":   "128MB",
-			"cpu":      "100m",
-			"replicas": 3,
-			"edge":     true,
-		},
+// limitError returns the error for an execution the runtime stopped at one
+// of its limits, or nil
+func (p *executionPayload) limitError(result *ExecutionResult) error {
+	switch p.Code {
+	case codeMemoryLimitExceeded:
+		return fmt.Errorf("%w: %s", ErrMemoryLimitExceeded, result.Error)
+	case codeExecutionTimeout:
+		return &ExecutionTimeoutError{
+			Elapsed: time.Duration(result.ExecutionTime * float64(time.Millisecond)),
+			Message: result.Error,
+		}
 	}
+	return nil
+}
 
-	if len(regions) > 0 {
-		requestData["region"] = regions[0]
-	}
+// ListModules lists all available WebAssembly modules. The response is
+// decoded incrementally; use StreamModules to process modules as they
+// arrive instead of collecting them all.
+func (c *Client) ListModules(opts ...CallOption) ([]*WasmModule, error) {
+	return c.ListModulesContext(context.Background(), opts...)
+}
 
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// ListModulesContext is like ListModules but stops when ctx is done
+func (c *Client) ListModulesContext(ctx context.Context, opts ...CallOption) ([]*WasmModule, error) {
+	req := &request{
+		op:     "list",
+		method: http.MethodGet,
+		path:   "/modules",
 	}
 
-	req, err := http.NewRequest("POST", c.config.APIURL+"/deployments", bytes.NewBuffer(jsonData))
+	v, err := c.reads.do(ctx, c.readKey(req, opts), func(ctx context.Context) (interface{}, error) {
+		var data []moduleRecord
+		err := c.doJSONList(ctx, req, opts, func(decode func(interface{}) error) error {
+			var rec moduleRecord
+			if err := decode(&rec); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+			data = append(data, rec)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	data := v.([]moduleRecord)
+	modules := make([]*WasmModule, len(data))
+	for i := range data {
+		modules[i] = data[i].toModule()
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+	return modules, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("deployment failed with status: %s", resp.Status)
+// GetModule fetches a single module by ID, or by a "name@version"
+// reference from ModuleVersion. A module that does not exist yields a
+// *ModuleNotFoundError, which matches ErrModuleNotFound.
+func (c *Client) GetModule(moduleID string, opts ...CallOption) (*WasmModule, error) {
+	return c.GetModuleContext(context.Background(), moduleID, opts...)
+}
+
+// GetModuleContext is like GetModule but stops when ctx is done
+func (c *Client) GetModuleContext(ctx context.Context, moduleID string, opts ...CallOption) (*WasmModule, error) {
+	path := "/modules/" + url.PathEscape(moduleID)
+	if name, version, ok := parseVersionRef(moduleID); ok {
+		path = "/modules/" + url.PathEscape(name) + "/versions/" + url.PathEscape(version)
 	}
 
-	var result struct {
-		Success bool                   `json:"success"`
-		Data    map[string]interface{} `json:"data"`
+	req := &request{
+		op:     "get module",
+		method: http.MethodGet,
+		path:   path,
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	v, err := c.reads.do(ctx, c.readKey(req, opts), func(ctx context.Context) (interface{}, error) {
+		var data moduleRecord
+		if err := c.doJSON(ctx, req, opts, &data); err != nil {
+			return nil, err
+		}
+		return &data, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, moduleNotFound(moduleID, err)
 	}
 
-	if !result.Success {
-		return nil, fmt.Errorf("deployment failed")
-	}
+	return v.(*moduleRecord).toModule(), nil
+}
 
-	return result.Data, nil
+// DeployToEdge deploys a module to edge locations, Config.DefaultRegions
+// if regions is empty, and returns the deployment. moduleID may be a
+// pinned "sha256:<hex>" reference. If some regions failed, the deployment
+// is returned together with a *RegionDeployError.
+func (c *Client) DeployToEdge(moduleID string, regions []string, opts ...CallOption) (*Deployment, error) {
+	return c.DeployToEdgeContext(context.Background(), moduleID, regions, opts...)
 }
 
-// ExecuteLocal executes WebAssembly module locally (simulated)
+// DeployToEdgeContext is like DeployToEdge but stops when ctx is done
+func (c *Client) DeployToEdgeContext(ctx context.Context, moduleID string, regions []string, opts ...CallOption) (*Deployment, error) {
+	return c.Deploy(ctx, moduleID, DeploySpec{Regions: regions}, opts...)
+}
+
+// DeployToEdgeWithConfig is like DeployToEdgeContext but sizes the
+// deployment with config instead of the defaults
+func (c *Client) DeployToEdgeWithConfig(ctx context.Context, moduleID string, regions []string, config DeployConfig, opts ...CallOption) (*Deployment, error) {
+	return c.Deploy(ctx, moduleID, DeploySpec{Regions: regions, Config: config}, opts...)
+}
+
+// ExecuteLocal runs a function of the module at wasmFilePath in-process,
+// without contacting the server. WASI imports are provided. A trap inside
+// the function is reported in the result's Error with Success false;
+// errors are returned for unreadable modules, unknown functions and args
+// that do not fit the signature.
 func ExecuteLocal(wasmFilePath, functionName string, args []interface{}) (*ExecutionResult, error) {
-	// This would integrate with Wasmtime Go bindings
-	// For now, we'll simulate local execution
-	startTime := time.Now()
-	
-	// Simulate execution
-	result := fmt.Sprintf("Executed %s with args %v", functionName, args)
-	executionTime := time.Since(startTime).Seconds() * 1000
+	return executeLocal(context.Background(), wasmFilePath, functionName, args, LocalConfig{})
+}
 
-	return &ExecutionResult{
-		Success:       true,
-		Result:        result,
-		ExecutionTime: executionTime,
-		MemoryUsed:    1024 * 1024, // 1MB
-		Error:         "",
-	}, nil
+// ExecuteLocalWithConfig is like ExecuteLocal but runs the module in the
+// WASI environment described by cfg, with its own environment variables,
+// args, preopened directories and standard streams. A module exceeding
+// the memory or time limits of cfg fails with Success false.
+func ExecuteLocalWithConfig(wasmFilePath, functionName string, args []interface{}, cfg LocalConfig) (*ExecutionResult, error) {
+	return executeLocal(context.Background(), wasmFilePath, functionName, args, cfg)
 }
 
 // Convenience functions
@@ -387,9 +1027,12 @@ func RunWasm(wasmFilePath, functionName string, args []interface{}) (interface{}
 	return result.Result, nil
 }
 
+// DeployToCloud uploads a module with the default client and deploys it,
+// globally if regions is empty. If some regions failed, the deployment ID
+// is returned together with a *RegionDeployError.
 func DeployToCloud(wasmFilePath, name string, regions []string) (string, error) {
 	client := NewDefaultClient()
-	
+
 	// Upload module
 	module, err := client.UploadModule(wasmFilePath, name, "1.0.0")
 	if err != nil {
@@ -397,10 +1040,9 @@ func DeployToCloud(wasmFilePath, name string, regions []string) (string, error)
 	}
 
 	// Deploy to edge
-	deployment, err := client.DeployToEdge(module.ID, regions)
-	if err != nil {
+	deployment, err := client.Deploy(context.Background(), module.ID, DeploySpec{Regions: regions})
+	if deployment == nil {
 		return "", err
 	}
-
-	return deployment["id"].(string), nil
-}
\ No newline at end of file
+	return deployment.ID, err
+}